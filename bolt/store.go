@@ -0,0 +1,117 @@
+// Package bolt implements a flamingo.ConversationStore backed by a BoltDB
+// file, so conversation state survives a process restart without needing
+// an external service.
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	boltdb "github.com/boltdb/bolt"
+)
+
+var bucketName = []byte("flamingo_conversations")
+
+// Store is a flamingo.ConversationStore backed by a BoltDB database.
+type Store struct {
+	db *boltdb.DB
+}
+
+// Open creates (if needed) the bucket Store keeps its entries in within db
+// and returns a Store backed by it.
+func Open(db *boltdb.DB) (*Store, error) {
+	err := db.Update(func(tx *boltdb.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+type entry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	HasTTL    bool      `json:"has_ttl"`
+}
+
+func (e entry) expired(now time.Time) bool {
+	return e.HasTTL && !e.ExpiresAt.After(now)
+}
+
+func entryKey(channel, user, key string) []byte {
+	return []byte(channel + "\x00" + user + "\x00" + key)
+}
+
+// Get returns the value stored under channel, user and key, and whether it
+// was found.
+func (s *Store) Get(channel, user, key string) (string, bool, error) {
+	var e entry
+	var found bool
+	err := s.db.View(func(tx *boltdb.Tx) error {
+		raw := tx.Bucket(bucketName).Get(entryKey(channel, user, key))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &e)
+	})
+	if err != nil || !found || e.expired(time.Now()) {
+		return "", false, err
+	}
+	return e.Value, true, nil
+}
+
+// Set stores value under channel, user and key. A zero ttl means the entry
+// never expires.
+func (s *Store) Set(channel, user, key, value string, ttl time.Duration) error {
+	e := entry{Value: value}
+	if ttl > 0 {
+		e.HasTTL = true
+		e.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *boltdb.Tx) error {
+		return tx.Bucket(bucketName).Put(entryKey(channel, user, key), raw)
+	})
+}
+
+// Delete removes the value stored under channel, user and key, if any.
+func (s *Store) Delete(channel, user, key string) error {
+	return s.db.Update(func(tx *boltdb.Tx) error {
+		return tx.Bucket(bucketName).Delete(entryKey(channel, user, key))
+	})
+}
+
+// Scan returns every non-expired key/value pair stored under channel and
+// user.
+func (s *Store) Scan(channel, user string) (map[string]string, error) {
+	prefix := entryKey(channel, user, "")
+	result := make(map[string]string)
+	now := time.Now()
+
+	err := s.db.View(func(tx *boltdb.Tx) error {
+		c := tx.Bucket(bucketName).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var e entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !e.expired(now) {
+				result[string(k[len(prefix):])] = e.Value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	boltdb "github.com/boltdb/bolt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStore(t *testing.T) *Store {
+	path := filepath.Join(t.TempDir(), "flamingo.db")
+	db, err := boltdb.Open(path, 0600, &boltdb.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestOpenCreatesBucket(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "flamingo.db")
+	db, err := boltdb.Open(path, 0600, &boltdb.Options{Timeout: time.Second})
+	assert.Nil(err)
+	defer db.Close()
+
+	_, err = Open(db)
+	assert.Nil(err)
+
+	err = db.View(func(tx *boltdb.Tx) error {
+		assert.NotNil(tx.Bucket(bucketName))
+		return nil
+	})
+	assert.Nil(err)
+}
+
+func TestGetSetDelete(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	_, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+
+	assert.Nil(store.Set("channel", "user", "key", "value", 0))
+	value, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.True(found)
+	assert.Equal("value", value)
+
+	assert.Nil(store.Delete("channel", "user", "key"))
+	_, found, err = store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+}
+
+func TestGetExpiresEntriesPastTheirTTL(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	assert.Nil(store.Set("channel", "user", "key", "value", time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+
+	_, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+}
+
+func TestEntryExpired(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+
+	assert.False(entry{HasTTL: false}.expired(now))
+	assert.False(entry{HasTTL: true, ExpiresAt: now.Add(time.Minute)}.expired(now))
+	assert.True(entry{HasTTL: true, ExpiresAt: now.Add(-time.Minute)}.expired(now))
+	assert.True(entry{HasTTL: true, ExpiresAt: now}.expired(now))
+}
+
+func TestScanReturnsOnlyEntriesUnderTheSamePrefix(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	assert.Nil(store.Set("channel", "user", "a", "1", 0))
+	assert.Nil(store.Set("channel", "user", "b", "2", 0))
+	assert.Nil(store.Set("channel", "other", "a", "3", 0))
+	assert.Nil(store.Set("other", "user", "a", "4", 0))
+
+	result, err := store.Scan("channel", "user")
+	assert.Nil(err)
+	assert.Equal(map[string]string{"a": "1", "b": "2"}, result)
+}
+
+func TestScanSkipsExpiredEntries(t *testing.T) {
+	assert := assert.New(t)
+	store := newStore(t)
+
+	assert.Nil(store.Set("channel", "user", "fresh", "1", 0))
+	assert.Nil(store.Set("channel", "user", "stale", "2", time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+
+	result, err := store.Scan("channel", "user")
+	assert.Nil(err)
+	assert.Equal(map[string]string{"fresh": "1"}, result)
+}
@@ -0,0 +1,119 @@
+package flamingo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by the HandlerFunc chain when RateLimiter
+// blocks a dispatch because its sender exceeded the configured rate.
+var ErrRateLimited = errors.New("flamingo: rate limit exceeded")
+
+// rated is implemented by payloads that carry a user identity RateLimiter
+// can key on. Message and Action both satisfy it.
+type rated interface {
+	rateKey() string
+}
+
+func (m Message) rateKey() string { return m.User }
+func (a Action) rateKey() string  { return a.User }
+
+// Recover returns a Middleware that recovers from panics raised further
+// down the chain, turning them into an error, and reporting them to onPanic
+// if it's non-nil.
+func Recover(onPanic func(recovered interface{})) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, bot Bot, payload interface{}) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if onPanic != nil {
+						onPanic(r)
+					}
+					err = fmt.Errorf("flamingo: recovered from panic: %v", r)
+				}
+			}()
+			return next(ctx, bot, payload)
+		}
+	}
+}
+
+// RequestLogger returns a Middleware that logs every dispatch through
+// logger, along with how long it took and the error it returned, if any.
+func RequestLogger(logger *log.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, bot Bot, payload interface{}) error {
+			start := time.Now()
+			err := next(ctx, bot, payload)
+			logger.Printf("dispatched %T in %s, err=%v", payload, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// RateLimiter returns a Middleware that allows at most limit dispatches per
+// window for each distinct user, returning ErrRateLimited once that's
+// exceeded. Payloads that don't carry a user identity (e.g. jobs) pass
+// through unlimited.
+func RateLimiter(limit int, window time.Duration) Middleware {
+	var mut sync.Mutex
+	hits := make(map[string][]time.Time)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, bot Bot, payload interface{}) error {
+			r, ok := payload.(rated)
+			if !ok || r.rateKey() == "" {
+				return next(ctx, bot, payload)
+			}
+
+			key := r.rateKey()
+			now := time.Now()
+			cutoff := now.Add(-window)
+
+			mut.Lock()
+			kept := hits[key][:0]
+			for _, t := range hits[key] {
+				if t.After(cutoff) {
+					kept = append(kept, t)
+				}
+			}
+			if len(kept) >= limit {
+				hits[key] = kept
+				mut.Unlock()
+				return ErrRateLimited
+			}
+			hits[key] = append(kept, now)
+			mut.Unlock()
+
+			return next(ctx, bot, payload)
+		}
+	}
+}
+
+// MetricsRecorder is the minimal surface the Metrics middleware needs from a
+// metrics backend (e.g. statsd or Prometheus client wrappers).
+type MetricsRecorder interface {
+	IncCounter(name string, tags ...string)
+	ObserveDuration(name string, d time.Duration, tags ...string)
+}
+
+// Metrics returns a Middleware that reports dispatch counts and latency to
+// recorder.
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, bot Bot, payload interface{}) error {
+			start := time.Now()
+			err := next(ctx, bot, payload)
+			recorder.ObserveDuration("flamingo.dispatch.duration", time.Since(start))
+			if err != nil {
+				recorder.IncCounter("flamingo.dispatch.error")
+			} else {
+				recorder.IncCounter("flamingo.dispatch.ok")
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package flamingo
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverTurnsAPanicIntoAnError(t *testing.T) {
+	assert := assert.New(t)
+	var recovered interface{}
+
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		panic("boom")
+	}
+
+	chain := Chain(Recover(func(r interface{}) { recovered = r }))(final)
+	err := chain(context.Background(), nil, nil)
+	assert.NotNil(err)
+	assert.Equal("boom", recovered)
+}
+
+func TestRecoverPassesThroughWhenNoPanic(t *testing.T) {
+	assert := assert.New(t)
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(Recover(nil))(final)
+	assert.Nil(chain(context.Background(), nil, nil))
+}
+
+func TestRequestLoggerLogsTheDispatch(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(RequestLogger(logger))(final)
+	assert.Nil(chain(context.Background(), nil, Message{Text: "hi"}))
+	assert.Contains(buf.String(), "flamingo.Message")
+}
+
+func TestRateLimiterBlocksOnceTheLimitIsExceeded(t *testing.T) {
+	assert := assert.New(t)
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(RateLimiter(2, time.Minute))(final)
+	msg := Message{User: "u1"}
+
+	assert.Nil(chain(context.Background(), nil, msg))
+	assert.Nil(chain(context.Background(), nil, msg))
+	assert.Equal(ErrRateLimited, chain(context.Background(), nil, msg))
+}
+
+func TestRateLimiterTracksEachUserSeparately(t *testing.T) {
+	assert := assert.New(t)
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(RateLimiter(1, time.Minute))(final)
+	assert.Nil(chain(context.Background(), nil, Message{User: "u1"}))
+	assert.Nil(chain(context.Background(), nil, Message{User: "u2"}))
+	assert.Equal(ErrRateLimited, chain(context.Background(), nil, Message{User: "u1"}))
+}
+
+func TestRateLimiterEvictsHitsOlderThanTheWindow(t *testing.T) {
+	assert := assert.New(t)
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(RateLimiter(1, 10*time.Millisecond))(final)
+	msg := Message{User: "u1"}
+
+	assert.Nil(chain(context.Background(), nil, msg))
+	assert.Equal(ErrRateLimited, chain(context.Background(), nil, msg))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(chain(context.Background(), nil, msg))
+}
+
+func TestRateLimiterLetsUnratedPayloadsThrough(t *testing.T) {
+	assert := assert.New(t)
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	}
+
+	chain := Chain(RateLimiter(1, time.Minute))(final)
+	job := Job{Name: "reminder"}
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(chain(context.Background(), nil, job))
+	}
+}
+
+type mockMetricsRecorder struct {
+	counters  []string
+	durations []string
+}
+
+func (r *mockMetricsRecorder) IncCounter(name string, tags ...string) {
+	r.counters = append(r.counters, name)
+}
+
+func (r *mockMetricsRecorder) ObserveDuration(name string, d time.Duration, tags ...string) {
+	r.durations = append(r.durations, name)
+}
+
+func TestMetricsRecordsOkAndErrorDispatches(t *testing.T) {
+	assert := assert.New(t)
+	recorder := &mockMetricsRecorder{}
+
+	ok := Chain(Metrics(recorder))(func(ctx context.Context, bot Bot, payload interface{}) error {
+		return nil
+	})
+	assert.Nil(ok(context.Background(), nil, nil))
+
+	failErr := ErrRateLimited
+	failing := Chain(Metrics(recorder))(func(ctx context.Context, bot Bot, payload interface{}) error {
+		return failErr
+	})
+	assert.Equal(failErr, failing(context.Background(), nil, nil))
+
+	assert.Equal([]string{"flamingo.dispatch.ok", "flamingo.dispatch.error"}, recorder.counters)
+	assert.Equal(2, len(recorder.durations))
+}
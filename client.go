@@ -0,0 +1,123 @@
+package flamingo
+
+import "sync"
+
+// Client runs one or more Backends together: controllers, action handlers,
+// the intro handler and middlewares registered on a Client are mirrored to
+// every Backend it wraps, and Run/Stop drive them all at once.
+//
+// Client intentionally has no Send method of its own. Backend.Send takes a
+// bare channel string with no indication of which backend it belongs to,
+// and Client keeps no routing table from channel to backend, so there is
+// no ownership policy it could apply that wouldn't either guess (broadcast
+// to every backend and swallow the N-1 failures that don't own the
+// channel) or require callers to pass the backend alongside the channel,
+// which just pushes the problem back onto them. A flamingo.Bot is already
+// scoped to the backend and conversation it was dispatched from, so
+// controllers send through Bot.Reply/Bot.Say instead of through Client.
+type Client struct {
+	mut      sync.RWMutex
+	backends []Backend
+}
+
+// NewClient creates a Client that drives the given backends.
+func NewClient(backends ...Backend) *Client {
+	return &Client{backends: backends}
+}
+
+// AddController registers ctrl with every backend.
+func (c *Client) AddController(ctrl Controller) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	for _, b := range c.backends {
+		b.AddController(ctrl)
+	}
+}
+
+// AddActionHandler registers handler under id with every backend.
+func (c *Client) AddActionHandler(id string, handler ActionHandler) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	for _, b := range c.backends {
+		b.AddActionHandler(id, handler)
+	}
+}
+
+// SetIntroHandler registers h with every backend.
+func (c *Client) SetIntroHandler(h IntroHandler) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	for _, b := range c.backends {
+		b.SetIntroHandler(h)
+	}
+}
+
+// HandleIntro runs channel's intro handling on every backend, returning the
+// first error any of them returned.
+func (c *Client) HandleIntro(bot Bot, channel Channel) error {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	var first error
+	for _, b := range c.backends {
+		if err := b.HandleIntro(bot, channel); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Use registers mw with every backend.
+func (c *Client) Use(mw ...Middleware) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	for _, b := range c.backends {
+		b.Use(mw...)
+	}
+}
+
+// UseFor registers mw under id with every backend.
+func (c *Client) UseFor(id string, mw ...Middleware) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	for _, b := range c.backends {
+		b.UseFor(id, mw...)
+	}
+}
+
+// Run starts every backend concurrently and blocks until all of them have
+// stopped, returning the first error any of them returned.
+func (c *Client) Run() error {
+	c.mut.RLock()
+	backends := append([]Backend(nil), c.backends...)
+	c.mut.RUnlock()
+
+	errs := make(chan error, len(backends))
+	for _, b := range backends {
+		b := b
+		go func() { errs <- b.Run() }()
+	}
+
+	var first error
+	for range backends {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stop stops every backend, returning the first error any of them
+// returned.
+func (c *Client) Stop() error {
+	c.mut.RLock()
+	backends := append([]Backend(nil), c.backends...)
+	c.mut.RUnlock()
+
+	var first error
+	for _, b := range backends {
+		if err := b.Stop(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,121 @@
+package flamingo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBackend struct {
+	runErr   error
+	stopErr  error
+	introErr error
+
+	controllers    []Controller
+	actionHandlers map[string]ActionHandler
+	introHandler   IntroHandler
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{actionHandlers: make(map[string]ActionHandler)}
+}
+
+func (b *mockBackend) Run() error  { return b.runErr }
+func (b *mockBackend) Stop() error { return b.stopErr }
+
+func (b *mockBackend) AddController(ctrl Controller) {
+	b.controllers = append(b.controllers, ctrl)
+}
+
+func (b *mockBackend) AddActionHandler(id string, handler ActionHandler) {
+	b.actionHandlers[id] = handler
+}
+
+func (b *mockBackend) SetIntroHandler(h IntroHandler) { b.introHandler = h }
+
+func (b *mockBackend) HandleIntro(bot Bot, channel Channel) error { return b.introErr }
+
+func (b *mockBackend) Use(mw ...Middleware)               {}
+func (b *mockBackend) UseFor(id string, mw ...Middleware) {}
+
+func (b *mockBackend) Send(channel string, msg OutgoingMessage) error { return nil }
+
+type noopController struct{}
+
+func (noopController) CanHandle(msg Message) bool        { return false }
+func (noopController) Handle(bot Bot, msg Message) error { return nil }
+
+func TestClientAddControllerMirrorsToEveryBackend(t *testing.T) {
+	assert := assert.New(t)
+	a, b := newMockBackend(), newMockBackend()
+	client := NewClient(a, b)
+
+	ctrl := noopController{}
+	client.AddController(ctrl)
+
+	assert.Equal(1, len(a.controllers))
+	assert.Equal(1, len(b.controllers))
+}
+
+func TestClientAddActionHandlerMirrorsToEveryBackend(t *testing.T) {
+	assert := assert.New(t)
+	a, b := newMockBackend(), newMockBackend()
+	client := NewClient(a, b)
+
+	client.AddActionHandler("foo", func(bot Bot, action Action) {})
+
+	assert.Equal(1, len(a.actionHandlers))
+	assert.Equal(1, len(b.actionHandlers))
+}
+
+func TestClientRunReturnsNilWhenEveryBackendStopsCleanly(t *testing.T) {
+	assert := assert.New(t)
+	client := NewClient(newMockBackend(), newMockBackend())
+	assert.Nil(client.Run())
+}
+
+func TestClientRunReturnsTheFirstErrorAcrossBackends(t *testing.T) {
+	assert := assert.New(t)
+	errA := errors.New("backend a failed")
+	errB := errors.New("backend b failed")
+
+	a := newMockBackend()
+	a.runErr = errA
+	b := newMockBackend()
+	b.runErr = errB
+
+	client := NewClient(a, b)
+	err := client.Run()
+	assert.True(err == errA || err == errB)
+}
+
+func TestClientStopStopsEveryBackendAndReturnsTheFirstError(t *testing.T) {
+	assert := assert.New(t)
+	errA := errors.New("backend a failed to stop")
+
+	a := newMockBackend()
+	a.stopErr = errA
+	b := newMockBackend()
+
+	client := NewClient(a, b)
+	assert.Equal(errA, client.Stop())
+}
+
+func TestClientHandleIntroReturnsTheFirstError(t *testing.T) {
+	assert := assert.New(t)
+	errA := errors.New("backend a intro failed")
+
+	a := newMockBackend()
+	a.introErr = errA
+	b := newMockBackend()
+
+	client := NewClient(a, b)
+	assert.Equal(errA, client.HandleIntro(nil, Channel{ID: "c"}))
+}
+
+func TestClientHandleIntroReturnsNilWhenNoBackendErrors(t *testing.T) {
+	assert := assert.New(t)
+	client := NewClient(newMockBackend(), newMockBackend())
+	assert.Nil(client.HandleIntro(nil, Channel{ID: "c"}))
+}
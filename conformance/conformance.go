@@ -0,0 +1,230 @@
+// Package conformance exercises the behavior every flamingo.Backend must
+// share, so the same suite can run against the slack, telegram and
+// mattermost implementations.
+package conformance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mvader/flamingo"
+	"github.com/stretchr/testify/assert"
+)
+
+// dispatcher is implemented by every concrete backend alongside
+// flamingo.Backend. flamingo.Backend itself has no way to simulate an
+// incoming message, action or job, so the suite drives dispatch through
+// the AddJobHandler and Dispatch* methods every backend gets from its
+// embedded *flamingo.Dispatcher instead.
+type dispatcher interface {
+	flamingo.Backend
+	AddJobHandler(name string, handler flamingo.JobHandler)
+	DispatchMessage(ctx context.Context, b flamingo.Bot, msg flamingo.Message) error
+	DispatchAction(ctx context.Context, b flamingo.Bot, id string, action flamingo.Action) error
+	DispatchJob(ctx context.Context, b flamingo.Bot, job flamingo.Job) error
+}
+
+// noopBot is a flamingo.Bot that does nothing, for dispatch subtests that
+// only care whether the right controller/handler ran.
+type noopBot struct{}
+
+func (noopBot) Reply(msg flamingo.Message, text string) error { return nil }
+func (noopBot) Say(channel, text string) error                { return nil }
+func (noopBot) State(key string) (string, bool, error)        { return "", false, nil }
+func (noopBot) SetState(key, value string, ttl time.Duration) error {
+	return nil
+}
+
+type introRecorder struct {
+	mut   sync.Mutex
+	calls int
+}
+
+func (r *introRecorder) HandleIntro(bot flamingo.Bot, channel flamingo.Channel) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.calls++
+	return nil
+}
+
+func (r *introRecorder) count() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.calls
+}
+
+// recordingController claims every Message whose Text equals want, and
+// records every one it's given.
+type recordingController struct {
+	mut  sync.Mutex
+	id   string
+	want string
+	msgs []flamingo.Message
+}
+
+func (c *recordingController) ID() string { return c.id }
+
+func (c *recordingController) CanHandle(msg flamingo.Message) bool {
+	return msg.Text == c.want
+}
+
+func (c *recordingController) Handle(bot flamingo.Bot, msg flamingo.Message) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func (c *recordingController) count() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return len(c.msgs)
+}
+
+// Run runs the conformance suite against a fresh backend produced by
+// newBackend for each sub-test.
+func Run(t *testing.T, newBackend func() flamingo.Backend) {
+	t.Run("HandleIntroIsNoopWithoutAHandler", func(t *testing.T) {
+		assert := assert.New(t)
+		b := newBackend()
+		assert.Nil(b.HandleIntro(nil, flamingo.Channel{}))
+	})
+
+	t.Run("HandleIntroRunsTheRegisteredHandler", func(t *testing.T) {
+		assert := assert.New(t)
+		b := newBackend()
+		rec := &introRecorder{}
+		b.SetIntroHandler(rec)
+		assert.Nil(b.HandleIntro(nil, flamingo.Channel{}))
+		assert.Equal(1, rec.count())
+	})
+
+	t.Run("UseWrapsDispatch", func(t *testing.T) {
+		assert := assert.New(t)
+		b := newBackend()
+
+		var called bool
+		b.Use(func(next flamingo.HandlerFunc) flamingo.HandlerFunc {
+			return func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+				called = true
+				return next(ctx, bot, payload)
+			}
+		})
+
+		rec := &introRecorder{}
+		b.SetIntroHandler(rec)
+		assert.Nil(b.HandleIntro(nil, flamingo.Channel{}))
+		assert.True(called)
+		assert.Equal(1, rec.count())
+	})
+
+	t.Run("DispatchMessageRunsTheClaimingController", func(t *testing.T) {
+		assert := assert.New(t)
+		d := asDispatcher(t, newBackend())
+
+		ctrl := &recordingController{id: "greeter", want: "hi"}
+		d.AddController(ctrl)
+
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "hi", Channel: "c1"}))
+		assert.Equal(1, ctrl.count())
+
+		// A message that doesn't match CanHandle, in a channel ctrl
+		// doesn't already own, is left undispatched.
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "bye", Channel: "c2"}))
+		assert.Equal(1, ctrl.count())
+	})
+
+	t.Run("DispatchMessageStaysWithTheOwningController", func(t *testing.T) {
+		assert := assert.New(t)
+		d := asDispatcher(t, newBackend())
+
+		ctrl := &recordingController{id: "greeter", want: "hi"}
+		d.AddController(ctrl)
+
+		// The first message claims the conversation by matching CanHandle.
+		// A second message in the same channel that wouldn't otherwise
+		// match should still be routed to ctrl, because it now owns the
+		// conversation.
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "hi", Channel: "c1"}))
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "anything", Channel: "c1"}))
+		assert.Equal(2, ctrl.count())
+
+		// A different channel has no owner yet, so the same message is
+		// left undispatched.
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "anything", Channel: "c2"}))
+		assert.Equal(2, ctrl.count())
+	})
+
+	t.Run("DispatchActionRunsTheRegisteredHandler", func(t *testing.T) {
+		assert := assert.New(t)
+		d := asDispatcher(t, newBackend())
+
+		var got flamingo.Action
+		var calls int
+		d.AddActionHandler("confirm", func(bot flamingo.Bot, action flamingo.Action) {
+			calls++
+			got = action
+		})
+
+		action := flamingo.Action{CallbackID: "confirm", Channel: "c1", Value: "yes"}
+		assert.Nil(d.DispatchAction(context.Background(), noopBot{}, "confirm", action))
+		assert.Equal(1, calls)
+		assert.Equal(action, got)
+
+		assert.Nil(d.DispatchAction(context.Background(), noopBot{}, "unknown", flamingo.Action{}))
+		assert.Equal(1, calls)
+	})
+
+	t.Run("DispatchJobRunsTheRegisteredHandler", func(t *testing.T) {
+		assert := assert.New(t)
+		d := asDispatcher(t, newBackend())
+
+		var calls int
+		d.AddJobHandler("reminder", func(bot flamingo.Bot, job flamingo.Job) {
+			calls++
+		})
+
+		assert.Nil(d.DispatchJob(context.Background(), noopBot{}, flamingo.Job{Name: "reminder"}))
+		assert.Equal(1, calls)
+
+		assert.Nil(d.DispatchJob(context.Background(), noopBot{}, flamingo.Job{Name: "unknown"}))
+		assert.Equal(1, calls)
+	})
+
+	t.Run("UseWrapsMessageActionAndJobDispatch", func(t *testing.T) {
+		assert := assert.New(t)
+		d := asDispatcher(t, newBackend())
+
+		var calls []string
+		d.Use(func(next flamingo.HandlerFunc) flamingo.HandlerFunc {
+			return func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+				calls = append(calls, "middleware")
+				return next(ctx, bot, payload)
+			}
+		})
+
+		ctrl := &recordingController{id: "greeter", want: "hi"}
+		d.AddController(ctrl)
+		d.AddActionHandler("confirm", func(bot flamingo.Bot, action flamingo.Action) {})
+		d.AddJobHandler("reminder", func(bot flamingo.Bot, job flamingo.Job) {})
+
+		assert.Nil(d.DispatchMessage(context.Background(), noopBot{}, flamingo.Message{Text: "hi", Channel: "c1"}))
+		assert.Nil(d.DispatchAction(context.Background(), noopBot{}, "confirm", flamingo.Action{CallbackID: "confirm"}))
+		assert.Nil(d.DispatchJob(context.Background(), noopBot{}, flamingo.Job{Name: "reminder"}))
+
+		assert.Equal([]string{"middleware", "middleware", "middleware"}, calls)
+	})
+}
+
+// asDispatcher requires b to implement dispatcher, failing the test
+// immediately otherwise: every backend this suite runs against is expected
+// to expose it.
+func asDispatcher(t *testing.T, b flamingo.Backend) dispatcher {
+	d, ok := b.(dispatcher)
+	if !ok {
+		t.Fatalf("backend %T does not implement the conformance dispatch interface", b)
+	}
+	return d
+}
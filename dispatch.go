@@ -0,0 +1,234 @@
+package flamingo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// conversationTTL bounds how long a controller keeps ownership of a
+// channel's conversation without a new message arriving in it.
+const conversationTTL = 10 * time.Minute
+
+// Dispatcher holds the controller/action-handler/job-handler registries,
+// the middleware chain, and the conversation-ownership bookkeeping that
+// every flamingo.Backend needs, whatever chat platform it talks to. A
+// Backend embeds a *Dispatcher and gets AddController, AddActionHandler,
+// AddJobHandler, SetIntroHandler, Use, UseFor and message/action/job
+// dispatch for free; it only has to implement the platform-specific parts:
+// Run, Stop, Send, HandleIntro (using IntroHandler and ChainFor), and
+// turning incoming events into Message/Action/Job values to hand to
+// DispatchMessage/DispatchAction/DispatchJob.
+type Dispatcher struct {
+	mut sync.RWMutex
+
+	// ownerKey namespaces conversation-ownership bookkeeping within store,
+	// so several backends can share one store without colliding.
+	ownerKey string
+	store    ConversationStore
+
+	controllers     []Controller
+	controllersByID map[string]Controller
+	actionHandlers  map[string]ActionHandler
+	jobHandlers     map[string]JobHandler
+	introHandler    IntroHandler
+
+	middlewares    []Middleware
+	middlewaresFor map[string][]Middleware
+}
+
+// NewDispatcher creates a Dispatcher that persists conversation ownership
+// on store, under ownerKey. A nil store defaults to an in-memory one,
+// which does not survive a process restart.
+func NewDispatcher(store ConversationStore, ownerKey string) *Dispatcher {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Dispatcher{
+		ownerKey:        ownerKey,
+		store:           store,
+		controllersByID: make(map[string]Controller),
+		actionHandlers:  make(map[string]ActionHandler),
+		jobHandlers:     make(map[string]JobHandler),
+		middlewaresFor:  make(map[string][]Middleware),
+	}
+}
+
+// Store returns the ConversationStore the Dispatcher was created with, for
+// a Backend's Bot implementation to use for its own State/SetState calls.
+func (d *Dispatcher) Store() ConversationStore {
+	return d.store
+}
+
+// AddController registers ctrl.
+func (d *Dispatcher) AddController(ctrl Controller) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.controllers = append(d.controllers, ctrl)
+	if identifiable, ok := ctrl.(Identifiable); ok {
+		d.controllersByID[identifiable.ID()] = ctrl
+	}
+}
+
+// ownerFor returns the controller that currently owns channel's
+// conversation, if the store still remembers one.
+func (d *Dispatcher) ownerFor(channel string) (Controller, bool) {
+	id, ok, err := d.store.Get(channel, "", d.ownerKey)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	d.mut.RLock()
+	ctrl, ok := d.controllersByID[id]
+	d.mut.RUnlock()
+	return ctrl, ok
+}
+
+// ControllerFor returns the first registered controller that can handle
+// msg.
+func (d *Dispatcher) ControllerFor(msg Message) (Controller, bool) {
+	d.mut.RLock()
+	defer d.mut.RUnlock()
+	for _, ctrl := range d.controllers {
+		if ctrl.CanHandle(msg) {
+			return ctrl, true
+		}
+	}
+	return nil, false
+}
+
+// AddActionHandler registers handler under id.
+func (d *Dispatcher) AddActionHandler(id string, handler ActionHandler) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.actionHandlers[id] = handler
+}
+
+// ActionHandler returns the handler registered under id, if any.
+func (d *Dispatcher) ActionHandler(id string) (ActionHandler, bool) {
+	d.mut.RLock()
+	defer d.mut.RUnlock()
+	handler, ok := d.actionHandlers[id]
+	return handler, ok
+}
+
+// AddJobHandler registers handler to run for every Job dispatched with the
+// given name.
+func (d *Dispatcher) AddJobHandler(name string, handler JobHandler) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.jobHandlers[name] = handler
+}
+
+// JobHandler returns the handler registered under name, if any.
+func (d *Dispatcher) JobHandler(name string) (JobHandler, bool) {
+	d.mut.RLock()
+	defer d.mut.RUnlock()
+	handler, ok := d.jobHandlers[name]
+	return handler, ok
+}
+
+// SetIntroHandler registers h.
+func (d *Dispatcher) SetIntroHandler(h IntroHandler) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.introHandler = h
+}
+
+// IntroHandler returns the registered intro handler, if any, for a
+// Backend's own HandleIntro to run.
+func (d *Dispatcher) IntroHandler() (IntroHandler, bool) {
+	d.mut.RLock()
+	defer d.mut.RUnlock()
+	return d.introHandler, d.introHandler != nil
+}
+
+// Use registers mw to run, in order, around every message, action, intro
+// and job dispatch.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.middlewares = append(d.middlewares, mw...)
+}
+
+// UseFor registers mw to run, in order, around dispatch addressed by id: a
+// controller whose ID() returns id, an action handler registered under id,
+// or a job whose Name is id. It runs after the middlewares registered via
+// Use.
+func (d *Dispatcher) UseFor(id string, mw ...Middleware) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	d.middlewaresFor[id] = append(d.middlewaresFor[id], mw...)
+}
+
+// ChainFor builds the middleware chain that applies to dispatch addressed
+// by id (the empty string for dispatch with no natural id, e.g. intros),
+// wrapping final. Backends with their own addressed dispatch outside of
+// Message/Action/Job (e.g. Slack's Events API events and slash commands)
+// use this directly.
+func (d *Dispatcher) ChainFor(id string, final HandlerFunc) HandlerFunc {
+	d.mut.RLock()
+	mws := make([]Middleware, 0, len(d.middlewares)+len(d.middlewaresFor[id]))
+	mws = append(mws, d.middlewares...)
+	if id != "" {
+		mws = append(mws, d.middlewaresFor[id]...)
+	}
+	d.mut.RUnlock()
+
+	return Chain(mws...)(final)
+}
+
+// DispatchMessage routes msg through the middleware chain to the
+// controller that claims it. A controller that already owns msg.Channel's
+// conversation is preferred over running CanHandle again, so a multi-step
+// conversation stays with the controller that started it.
+func (d *Dispatcher) DispatchMessage(ctx context.Context, b Bot, msg Message) error {
+	ctrl, ok := d.ownerFor(msg.Channel)
+	if !ok {
+		ctrl, ok = d.ControllerFor(msg)
+	}
+	if !ok {
+		return nil
+	}
+
+	id := ""
+	if identifiable, ok := ctrl.(Identifiable); ok {
+		id = identifiable.ID()
+		if err := d.store.Set(msg.Channel, "", d.ownerKey, id, conversationTTL); err != nil {
+			return err
+		}
+	}
+
+	chain := d.ChainFor(id, func(ctx context.Context, b Bot, payload interface{}) error {
+		return ctrl.Handle(b, payload.(Message))
+	})
+	return chain(ctx, b, msg)
+}
+
+// DispatchAction routes action through the middleware chain to the handler
+// registered under id, if any.
+func (d *Dispatcher) DispatchAction(ctx context.Context, b Bot, id string, action Action) error {
+	chain := d.ChainFor(id, func(ctx context.Context, b Bot, payload interface{}) error {
+		handler, ok := d.ActionHandler(id)
+		if !ok {
+			return nil
+		}
+		handler(b, payload.(Action))
+		return nil
+	})
+	return chain(ctx, b, action)
+}
+
+// DispatchJob routes job through the middleware chain to the handler
+// registered under its name, if any.
+func (d *Dispatcher) DispatchJob(ctx context.Context, b Bot, job Job) error {
+	chain := d.ChainFor(job.Name, func(ctx context.Context, b Bot, payload interface{}) error {
+		handler, ok := d.JobHandler(job.Name)
+		if !ok {
+			return nil
+		}
+		handler(b, payload.(Job))
+		return nil
+	})
+	return chain(ctx, b, job)
+}
@@ -0,0 +1,121 @@
+// Package flamingo provides the core abstractions for building chat bots:
+// messages, actions, controllers and the client that wires them together.
+// Concrete chat platforms (see the slack, telegram and mattermost
+// subpackages) implement Backend; Client runs one or more of them
+// together so controllers work unchanged across platforms.
+package flamingo
+
+import "time"
+
+// Message is an incoming chat message routed to a Controller.
+type Message struct {
+	Text    string
+	User    string
+	Channel string
+}
+
+// ChannelKind distinguishes the kinds of conversation a Channel can be.
+type ChannelKind int
+
+const (
+	// ChannelPublic is a public channel any workspace member can join.
+	ChannelPublic ChannelKind = iota
+	// ChannelPrivate is an invite-only channel.
+	ChannelPrivate
+	// ChannelMPIM is a private, multi-person direct message.
+	ChannelMPIM
+	// ChannelIM is a one-on-one direct message.
+	ChannelIM
+)
+
+// Channel identifies a conversation a bot is part of. Kind is the zero
+// value, ChannelPublic, for backends that don't distinguish conversation
+// kinds.
+type Channel struct {
+	ID   string
+	Name string
+	Kind ChannelKind
+}
+
+// Action is an interactive callback (e.g. a button click on a message
+// attachment) routed to the ActionHandler registered under its CallbackID.
+type Action struct {
+	CallbackID string
+	Channel    string
+	User       string
+	Value      string
+}
+
+// Job is a unit of asynchronous work handed to a bot outside of the regular
+// message/action flow (e.g. a scheduled reminder).
+type Job struct {
+	Name string
+	Data interface{}
+}
+
+// Bot is the handle controllers use to talk back to the chat platform.
+type Bot interface {
+	Reply(msg Message, text string) error
+	Say(channel, text string) error
+
+	// State returns scratch state previously stored by SetState for this
+	// bot's user, and whether it was found.
+	State(key string) (string, bool, error)
+	// SetState stores value as scratch state for this bot's user, scoped
+	// to the channel the current dispatch is happening in. A zero ttl
+	// means the entry never expires.
+	SetState(key, value string, ttl time.Duration) error
+}
+
+// Controller decides whether it can handle a Message and, if so, handles it.
+type Controller interface {
+	CanHandle(msg Message) bool
+	Handle(bot Bot, msg Message) error
+}
+
+// IntroHandler is implemented by controllers that want to greet a channel
+// the first time a bot joins it.
+type IntroHandler interface {
+	HandleIntro(bot Bot, channel Channel) error
+}
+
+// ActionHandler handles an Action routed to it by its registered id.
+type ActionHandler func(bot Bot, action Action)
+
+// JobHandler handles a Job routed to it by its registered name.
+type JobHandler func(bot Bot, job Job)
+
+// OutgoingMessage is a message a controller wants to send, described in
+// terms common across backends. Extra carries backend-specific extensions
+// that have no common representation (e.g. Slack attachments or Telegram
+// inline keyboards); each backend documents the keys it looks for there.
+type OutgoingMessage struct {
+	Text  string
+	Extra map[string]interface{}
+}
+
+// Backend is implemented by a chat-platform adapter (see the slack,
+// telegram and mattermost packages). Controllers written against
+// Bot/Message/Action work unchanged across backends.
+type Backend interface {
+	Run() error
+	Stop() error
+
+	AddController(ctrl Controller)
+	AddActionHandler(id string, handler ActionHandler)
+	SetIntroHandler(h IntroHandler)
+	HandleIntro(bot Bot, channel Channel) error
+
+	// Use registers mw to run, in order, around every message, action,
+	// intro and job dispatch.
+	Use(mw ...Middleware)
+	// UseFor registers mw to run, in order, around dispatch addressed by
+	// id: a controller whose ID() returns id, an action handler
+	// registered under id, or a job whose Name is id. It runs after the
+	// middlewares registered via Use.
+	UseFor(id string, mw ...Middleware)
+
+	// Send delivers msg to channel. Fields of msg this backend has no
+	// equivalent for are ignored.
+	Send(channel string, msg OutgoingMessage) error
+}
@@ -0,0 +1,232 @@
+// Package mattermost implements a flamingo.Backend backed by Mattermost's
+// WebSocket event stream and REST API.
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mvader/flamingo"
+)
+
+// ownerStateKey is the key mattermostClient uses on its ConversationStore
+// to remember which controller owns a channel's conversation.
+const ownerStateKey = "mattermost.owner"
+
+// ClientOptions configures a mattermost client.
+type ClientOptions struct {
+	// HTTPClient is used for REST API calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// Debug is accepted but not read: the client logs nothing about
+	// received events, whatever its value.
+	Debug bool
+}
+
+type mattermostClient struct {
+	mut sync.RWMutex
+
+	serverURL string
+	token     string
+	http      *http.Client
+
+	conn *websocket.Conn
+
+	*flamingo.Dispatcher
+
+	introSeen map[string]bool
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewClient creates a flamingo.Backend that talks to a Mattermost server.
+// serverURL is the server's base URL (e.g. "https://chat.example.com") and
+// token a personal access token or bot token.
+func NewClient(serverURL, token string, options ClientOptions) flamingo.Backend {
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &mattermostClient{
+		serverURL:  serverURL,
+		token:      token,
+		http:       httpClient,
+		Dispatcher: flamingo.NewDispatcher(nil, ownerStateKey),
+		introSeen:  make(map[string]bool),
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+}
+
+func (c *mattermostClient) HandleIntro(b flamingo.Bot, channel flamingo.Channel) error {
+	h, ok := c.IntroHandler()
+	if !ok {
+		return nil
+	}
+
+	chain := c.ChainFor("", func(ctx context.Context, b flamingo.Bot, payload interface{}) error {
+		return h.HandleIntro(b, payload.(flamingo.Channel))
+	})
+	return chain(context.Background(), b, channel)
+}
+
+// Run dials the WebSocket event stream, authenticates, and dispatches
+// events until Stop is called.
+func (c *mattermostClient) Run() error {
+	wsURL, err := websocketURL(c.serverURL)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		return err
+	}
+	c.mut.Lock()
+	c.conn = conn
+	c.mut.Unlock()
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"seq":    1,
+		"action": "authentication_challenge",
+		"data":   map[string]string{"token": c.token},
+	}); err != nil {
+		return err
+	}
+
+	go func() {
+		<-c.stop
+		conn.Close()
+	}()
+
+	for {
+		var evt wsEvent
+		if err := conn.ReadJSON(&evt); err != nil {
+			close(c.stopped)
+			return nil
+		}
+		c.handleEvent(evt)
+	}
+}
+
+// Stop closes the WebSocket connection and stops the event loop.
+func (c *mattermostClient) Stop() error {
+	close(c.stop)
+	<-c.stopped
+	return nil
+}
+
+// Send creates a post in channelID via the REST API.
+func (c *mattermostClient) Send(channelID string, msg flamingo.OutgoingMessage) error {
+	body, err := json.Marshal(map[string]string{
+		"channel_id": channelID,
+		"message":    msg.Text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.serverURL+"/api/v4/posts", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("mattermost: createPost: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// wsEvent is just enough of a Mattermost WebSocket event envelope to route
+// on its Event field; Data is decoded further depending on that.
+type wsEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type postedData struct {
+	Post string `json:"post"`
+}
+
+type post struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	Message   string `json:"message"`
+}
+
+type userAddedData struct {
+	ChannelID string `json:"channel_id"`
+}
+
+func (c *mattermostClient) handleEvent(evt wsEvent) {
+	ctx := context.Background()
+
+	switch evt.Event {
+	case "posted":
+		var data postedData
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			return
+		}
+		var p post
+		if err := json.Unmarshal([]byte(data.Post), &p); err != nil {
+			return
+		}
+		msg := flamingo.Message{Text: p.Message, User: p.UserID, Channel: p.ChannelID}
+		bot := &mattermostBot{client: c, channel: p.ChannelID, user: p.UserID}
+		_ = c.DispatchMessage(ctx, bot, msg)
+
+	case "user_added":
+		var data userAddedData
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			return
+		}
+		bot := &mattermostBot{client: c, channel: data.ChannelID}
+		c.mut.Lock()
+		already := c.introSeen[data.ChannelID]
+		c.introSeen[data.ChannelID] = true
+		c.mut.Unlock()
+		if !already {
+			_ = c.HandleIntro(bot, flamingo.Channel{ID: data.ChannelID})
+		}
+	}
+}
+
+// mattermostBot is the flamingo.Bot given to controllers handling
+// Mattermost events. channel and user scope its State/SetState calls to
+// the conversation the event is happening in.
+type mattermostBot struct {
+	client  *mattermostClient
+	channel string
+	user    string
+}
+
+func (b *mattermostBot) Reply(msg flamingo.Message, text string) error {
+	return b.client.Send(msg.Channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *mattermostBot) Say(channel, text string) error {
+	return b.client.Send(channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *mattermostBot) State(key string) (string, bool, error) {
+	return b.client.Store().Get(b.channel, b.user, key)
+}
+
+func (b *mattermostBot) SetState(key, value string, ttl time.Duration) error {
+	return b.client.Store().Set(b.channel, b.user, key, value, ttl)
+}
@@ -0,0 +1,26 @@
+package mattermost
+
+import (
+	"net/url"
+	"strings"
+)
+
+// websocketURL turns a Mattermost server's base URL into its WebSocket
+// event stream endpoint, e.g. "https://chat.example.com" becomes
+// "wss://chat.example.com/api/v4/websocket".
+func websocketURL(serverURL string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v4/websocket"
+
+	return u.String(), nil
+}
@@ -0,0 +1,19 @@
+package mattermost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebsocketURL(t *testing.T) {
+	assert := assert.New(t)
+
+	wsURL, err := websocketURL("https://chat.example.com")
+	assert.Nil(err)
+	assert.Equal("wss://chat.example.com/api/v4/websocket", wsURL)
+
+	wsURL, err = websocketURL("http://localhost:8065/")
+	assert.Nil(err)
+	assert.Equal("ws://localhost:8065/api/v4/websocket", wsURL)
+}
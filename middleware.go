@@ -0,0 +1,36 @@
+package flamingo
+
+import "context"
+
+// HandlerFunc is the uniform signature the middleware chain dispatches
+// through. payload is whichever of Message, Action, Channel (for intros) or
+// Job is being routed, or a backend's own type for dispatch it builds with
+// Dispatcher.ChainFor (e.g. Slack's Event and SlashCommand); middlewares
+// that care about its shape type-assert it.
+type HandlerFunc func(ctx context.Context, bot Bot, payload interface{}) error
+
+// Middleware wraps a HandlerFunc with another one. Implementations may
+// inspect or replace payload before calling next, skip next entirely to
+// short-circuit dispatch, or inspect the error next returns.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Identifiable is implemented by controllers that want per-controller
+// middleware registered via a Client's UseFor method. Action handlers are
+// already addressed by the id they're registered under, so they don't need
+// this interface to take part in the same scheme.
+type Identifiable interface {
+	ID() string
+}
+
+// Chain composes mws into a single Middleware, applied in the order given:
+// the first middleware in mws is the outermost one, running first on the
+// way in and last on the way out.
+func Chain(mws ...Middleware) Middleware {
+	return func(final HandlerFunc) HandlerFunc {
+		h := final
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
@@ -0,0 +1,65 @@
+package flamingo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainRunsMiddlewaresInOrder(t *testing.T) {
+	assert := assert.New(t)
+	var order []string
+
+	mark := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, bot Bot, payload interface{}) error {
+				order = append(order, name+":in")
+				err := next(ctx, bot, payload)
+				order = append(order, name+":out")
+				return err
+			}
+		}
+	}
+
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		order = append(order, "final")
+		return nil
+	}
+
+	chain := Chain(mark("a"), mark("b"))(final)
+	assert.Nil(chain(context.Background(), nil, nil))
+	assert.Equal([]string{"a:in", "b:in", "final", "b:out", "a:out"}, order)
+}
+
+func TestChainWithNoMiddlewaresReturnsFinalUnchanged(t *testing.T) {
+	assert := assert.New(t)
+	called := false
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		called = true
+		return nil
+	}
+
+	chain := Chain()(final)
+	assert.Nil(chain(context.Background(), nil, nil))
+	assert.True(called)
+}
+
+func TestChainShortCircuitsWhenAMiddlewareSkipsNext(t *testing.T) {
+	assert := assert.New(t)
+	finalCalled := false
+	final := func(ctx context.Context, bot Bot, payload interface{}) error {
+		finalCalled = true
+		return nil
+	}
+
+	skip := func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, bot Bot, payload interface{}) error {
+			return nil
+		}
+	}
+
+	chain := Chain(skip)(final)
+	assert.Nil(chain(context.Background(), nil, nil))
+	assert.False(finalCalled)
+}
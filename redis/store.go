@@ -0,0 +1,74 @@
+// Package redis implements a flamingo.ConversationStore backed by Redis, so
+// conversation state survives a process restart and can be shared by
+// several client instances.
+package redis
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Store is a flamingo.ConversationStore backed by a Redis instance.
+type Store struct {
+	client *redis.Client
+	prefix string
+}
+
+// New creates a Store that stores entries on client, namespaced under
+// prefix so several flamingo clients can share one Redis database.
+func New(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+func (s *Store) key(channel, user, key string) string {
+	return s.prefix + ":" + channel + ":" + user + ":" + key
+}
+
+// Get returns the value stored under channel, user and key, and whether it
+// was found.
+func (s *Store) Get(channel, user, key string) (string, bool, error) {
+	value, err := s.client.Get(s.key(channel, user, key)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under channel, user and key. A zero ttl means the entry
+// never expires.
+func (s *Store) Set(channel, user, key, value string, ttl time.Duration) error {
+	return s.client.Set(s.key(channel, user, key), value, ttl).Err()
+}
+
+// Delete removes the value stored under channel, user and key, if any.
+func (s *Store) Delete(channel, user, key string) error {
+	return s.client.Del(s.key(channel, user, key)).Err()
+}
+
+// Scan returns every key/value pair stored under channel and user.
+func (s *Store) Scan(channel, user string) (map[string]string, error) {
+	prefix := s.key(channel, user, "")
+	result := make(map[string]string)
+
+	iter := s.client.Scan(0, prefix+"*", 0).Iterator()
+	for iter.Next() {
+		full := iter.Val()
+		value, err := s.client.Get(full).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[strings.TrimPrefix(full, prefix)] = value
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return New(client, "flamingo"), mr
+}
+
+func TestGetSetDelete(t *testing.T) {
+	assert := assert.New(t)
+	store, _ := newStore(t)
+
+	_, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+
+	assert.Nil(store.Set("channel", "user", "key", "value", 0))
+	value, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.True(found)
+	assert.Equal("value", value)
+
+	assert.Nil(store.Delete("channel", "user", "key"))
+	_, found, err = store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+}
+
+func TestGetExpiresEntriesPastTheirTTL(t *testing.T) {
+	assert := assert.New(t)
+	store, mr := newStore(t)
+
+	assert.Nil(store.Set("channel", "user", "key", "value", 10*time.Millisecond))
+	_, found, err := store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.True(found)
+
+	mr.FastForward(20 * time.Millisecond)
+	_, found, err = store.Get("channel", "user", "key")
+	assert.Nil(err)
+	assert.False(found)
+}
+
+func TestScanReturnsOnlyEntriesUnderTheSamePrefix(t *testing.T) {
+	assert := assert.New(t)
+	store, _ := newStore(t)
+
+	assert.Nil(store.Set("channel", "user", "a", "1", 0))
+	assert.Nil(store.Set("channel", "user", "b", "2", 0))
+	assert.Nil(store.Set("channel", "other", "a", "3", 0))
+	assert.Nil(store.Set("other", "user", "a", "4", 0))
+
+	result, err := store.Scan("channel", "user")
+	assert.Nil(err)
+	assert.Equal(map[string]string{"a": "1", "b": "2"}, result)
+}
@@ -0,0 +1,35 @@
+package slack
+
+// AttachmentActionCallback is the payload Slack posts to the interactive-
+// message webhook when a user clicks a button or selects an option from a
+// message attachment. The pinned mvader/slack client predates interactive
+// messages and has no type for this, so it's hand-rolled here the way
+// Event/SlashCommand are in events.go.
+type AttachmentActionCallback struct {
+	Token      string             `json:"token"`
+	CallbackID string             `json:"callback_id"`
+	Channel    ActionChannel      `json:"channel"`
+	User       ActionUser         `json:"user"`
+	Actions    []AttachmentAction `json:"actions"`
+}
+
+// ActionChannel identifies the channel an interactive-message action
+// happened in.
+type ActionChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ActionUser identifies the user who triggered an interactive-message
+// action.
+type ActionUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AttachmentAction is a single button or menu selection within an
+// AttachmentActionCallback.
+type AttachmentAction struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
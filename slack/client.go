@@ -0,0 +1,357 @@
+// Package slack implements a flamingo.Backend backed by the Slack RTM and
+// Web APIs, plus an optional webhook server for interactive message actions.
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mvader/flamingo"
+	"github.com/mvader/slack"
+)
+
+// ownerStateKey namespaces this backend's conversation-ownership
+// bookkeeping within a ConversationStore that might be shared with other
+// backends.
+const ownerStateKey = "slack.owner"
+
+// introStateKey is the key slackClient uses on its ConversationStore to
+// remember whether a channel's intro has already fired.
+const introStateKey = "slack.intro_fired"
+
+// bot is the internal, backend-specific handle slackClient keeps for each
+// connected RTM session. It is distinct from flamingo.Bot, which is the
+// surface controllers are given.
+type bot interface {
+	stop()
+	handleAction(channel string, action AttachmentActionCallback)
+	handleJob(job flamingo.Job)
+}
+
+// ClientOptions configures a slackClient.
+type ClientOptions struct {
+	// WebhookAddr is the address the interactive-action webhook server
+	// listens on, e.g. ":8080".
+	WebhookAddr string
+	// EnableWebhook starts the webhook server when the client runs.
+	EnableWebhook bool
+	// EnableRTM starts a real RTM connection when the client runs, feeding
+	// ordinary chat messages into DispatchMessage. Off by default so tests
+	// and callers that only need the webhook (actions, slash commands,
+	// Events API) don't pay for a live connection to Slack.
+	EnableRTM bool
+	// SigningSecret is the app's signing secret, used to verify that
+	// incoming webhook requests genuinely come from Slack. When empty, no
+	// verification is performed.
+	SigningSecret string
+	// CommandsPath is the path slash commands are posted to. Defaults to
+	// "/commands".
+	CommandsPath string
+	// Store persists conversation ownership, intro state and per-user
+	// scratch state across restarts. Defaults to an in-memory store, which
+	// does not survive one.
+	Store flamingo.ConversationStore
+	// Debug is kept with the options, but nothing in this package reads
+	// it: it turns on no logging of the RTM connection or anything else.
+	Debug bool
+}
+
+type slackClient struct {
+	mut sync.RWMutex
+
+	token   string
+	options ClientOptions
+
+	bots map[string]bot
+
+	*flamingo.Dispatcher
+
+	eventHandlers map[string]EventHandler
+	slashHandlers map[string]SlashHandler
+
+	server          *http.Server
+	shutdownWebhook chan struct{}
+	stopped         chan struct{}
+}
+
+// NewClient creates a flamingo.Backend that talks to Slack. token is a bot
+// user OAuth token.
+func NewClient(token string, options ClientOptions) flamingo.Backend {
+	return &slackClient{
+		token:           token,
+		options:         options,
+		bots:            make(map[string]bot),
+		Dispatcher:      flamingo.NewDispatcher(options.Store, ownerStateKey),
+		eventHandlers:   make(map[string]EventHandler),
+		slashHandlers:   make(map[string]SlashHandler),
+		shutdownWebhook: make(chan struct{}, 1),
+		stopped:         make(chan struct{}),
+	}
+}
+
+// HandleIntro runs the registered intro handler for channel, unless it has
+// already fired for this channel according to the store.
+func (c *slackClient) HandleIntro(b flamingo.Bot, channel flamingo.Channel) error {
+	h, ok := c.IntroHandler()
+	if !ok {
+		return nil
+	}
+
+	fired, found, err := c.Store().Get(channel.ID, "", introStateKey)
+	if err != nil {
+		return err
+	}
+	if found && fired == "1" {
+		return nil
+	}
+
+	chain := c.ChainFor("", func(ctx context.Context, b flamingo.Bot, payload interface{}) error {
+		return h.HandleIntro(b, payload.(flamingo.Channel))
+	})
+	if err := chain(context.Background(), b, channel); err != nil {
+		return err
+	}
+	return c.Store().Set(channel.ID, "", introStateKey, "1", 0)
+}
+
+func (c *slackClient) Run() error {
+	if c.options.EnableWebhook {
+		go c.runWebhook()
+	}
+
+	if c.options.EnableRTM {
+		if err := c.runRTM(); err != nil {
+			return err
+		}
+	}
+
+	<-c.stopped
+	return nil
+}
+
+// runRTM establishes a real RTM connection and starts dispatching the
+// messages it receives through DispatchMessage.
+func (c *slackClient) runRTM() error {
+	ws, err := slack.New(c.token).StartRTM("", "https://slack.com/")
+	if err != nil {
+		return err
+	}
+
+	b := newRTMBot(c, ws)
+	c.mut.Lock()
+	c.bots[c.token] = b
+	c.mut.Unlock()
+
+	go b.run()
+	return nil
+}
+
+func (c *slackClient) Stop() error {
+	c.mut.RLock()
+	bots := make([]bot, 0, len(c.bots))
+	for _, b := range c.bots {
+		bots = append(bots, b)
+	}
+	c.mut.RUnlock()
+
+	for _, b := range bots {
+		b.stop()
+	}
+
+	select {
+	case c.shutdownWebhook <- struct{}{}:
+	default:
+	}
+	close(c.stopped)
+	return nil
+}
+
+func (c *slackClient) runWebhook() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.handleRoot)
+	mux.HandleFunc(c.commandsPath(), c.handleSlashCommand)
+
+	c.mut.Lock()
+	c.server = &http.Server{Addr: c.options.WebhookAddr, Handler: mux}
+	server := c.server
+	c.mut.Unlock()
+
+	go func() {
+		<-c.shutdownWebhook
+		server.Close()
+	}()
+
+	server.ListenAndServe()
+}
+
+// Send posts msg to channel using the Web API. msg.Extra["attachments"], if
+// present and of type []slack.Attachment, is attached to the message.
+func (c *slackClient) Send(channel string, msg flamingo.OutgoingMessage) error {
+	api := slack.New(c.token)
+	var params slack.PostMessageParameters
+	if attachments, ok := msg.Extra["attachments"].([]slack.Attachment); ok {
+		params.Attachments = attachments
+	}
+	_, _, err := api.PostMessage(channel, msg.Text, params)
+	return err
+}
+
+// slackBot is the flamingo.Bot given to controllers and handlers dispatched
+// from the webhook endpoint. channel and user scope its State/SetState
+// calls to the conversation the dispatch is happening in.
+type slackBot struct {
+	client  *slackClient
+	channel string
+	user    string
+}
+
+func (b *slackBot) Reply(msg flamingo.Message, text string) error {
+	return b.client.Send(msg.Channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *slackBot) Say(channel, text string) error {
+	return b.client.Send(channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *slackBot) State(key string) (string, bool, error) {
+	return b.client.Store().Get(b.channel, b.user, key)
+}
+
+func (b *slackBot) SetState(key, value string, ttl time.Duration) error {
+	return b.client.Store().Set(b.channel, b.user, key, value, ttl)
+}
+
+// rtmBot wires a live RTM connection to DispatchMessage. The pinned
+// mvader/slack client exposes no way to close a *slack.SlackWS or cancel
+// HandleIncomingEvents, so stop only halts dispatch from this bot; the
+// underlying read loop keeps running in the background until the process
+// exits.
+type rtmBot struct {
+	client *slackClient
+	ws     *slack.SlackWS
+
+	mut    sync.RWMutex
+	active bool
+}
+
+func newRTMBot(client *slackClient, ws *slack.SlackWS) *rtmBot {
+	return &rtmBot{client: client, ws: ws, active: true}
+}
+
+func (b *rtmBot) stop() {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.active = false
+}
+
+func (b *rtmBot) isActive() bool {
+	b.mut.RLock()
+	defer b.mut.RUnlock()
+	return b.active
+}
+
+// handleAction and handleJob are no-ops for rtmBot: interactive-message
+// actions and jobs never arrive over the RTM connection, only through the
+// webhook server.
+func (b *rtmBot) handleAction(channel string, action AttachmentActionCallback) {}
+
+func (b *rtmBot) handleJob(job flamingo.Job) {}
+
+// run reads events off the RTM connection and dispatches Slack messages
+// until stop is called.
+func (b *rtmBot) run() {
+	events := make(chan slack.SlackEvent)
+	go b.ws.HandleIncomingEvents(events)
+
+	for event := range events {
+		if !b.isActive() {
+			continue
+		}
+
+		msg, ok := event.Data.(*slack.MessageEvent)
+		if !ok || msg.Text == "" {
+			continue
+		}
+
+		m := flamingo.Message{Text: msg.Text, User: msg.UserId, Channel: msg.ChannelId}
+		sb := &slackBot{client: b.client, channel: msg.ChannelId, user: msg.UserId}
+		_ = b.client.DispatchMessage(context.Background(), sb, m)
+	}
+}
+
+func (c *slackClient) commandsPath() string {
+	if c.options.CommandsPath == "" {
+		return "/commands"
+	}
+	return c.options.CommandsPath
+}
+
+// envelope is just enough of the Events API envelope to tell apart the
+// three kinds of POST the root endpoint receives: url_verification,
+// event_callback, and legacy interactive-message action callbacks (which
+// carry no "type" field at all).
+type envelope struct {
+	Type string `json:"type"`
+}
+
+// handleRoot serves interactive-message action callbacks as well as the
+// Events API: URL verification handshakes and event_callback envelopes.
+func (c *slackClient) handleRoot(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !c.verifyRequest(r, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch env.Type {
+	case "url_verification":
+		c.handleURLVerification(w, body)
+	case "event_callback":
+		c.handleEventCallback(r.Context(), w, body)
+	default:
+		c.handleActionCallback(r.Context(), w, body)
+	}
+}
+
+func (c *slackClient) handleActionCallback(ctx context.Context, w http.ResponseWriter, body []byte) {
+	var callback AttachmentActionCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mut.RLock()
+	b, ok := c.bots[callback.Token]
+	c.mut.RUnlock()
+	if ok {
+		b.handleAction(callback.Channel.ID, callback)
+	}
+
+	action := flamingo.Action{
+		CallbackID: callback.CallbackID,
+		Channel:    callback.Channel.ID,
+		User:       callback.User.ID,
+	}
+	if len(callback.Actions) > 0 {
+		action.Value = callback.Actions[0].Value
+	}
+	sb := &slackBot{client: c, channel: action.Channel, user: action.User}
+	_ = c.DispatchAction(ctx, sb, callback.CallbackID, action)
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -2,14 +2,18 @@ package slack
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
 	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/mvader/flamingo"
-	"github.com/mvader/slack"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,6 +84,13 @@ func TestRunAndStopWebhook(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(resp.StatusCode, http.StatusOK)
 
+	resp, err = http.Post("http://127.0.0.1:8989", "application/json", bytes.NewBuffer([]byte(testURLVerification)))
+	assert.Nil(err)
+	assert.Equal(resp.StatusCode, http.StatusOK)
+	var challenge urlVerification
+	assert.Nil(json.NewDecoder(resp.Body).Decode(&challenge))
+	assert.Equal("a_challenge_value", challenge.Challenge)
+
 	cli.shutdownWebhook <- struct{}{}
 	<-time.After(50 * time.Millisecond)
 
@@ -90,10 +101,55 @@ func TestRunAndStopWebhook(t *testing.T) {
 	assert.NotNil(err)
 }
 
+func TestWebhookSignatureVerification(t *testing.T) {
+	assert := assert.New(t)
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+	cli := newClient("xAB3yVzGS4BQ3O9FACTa8Ho4", ClientOptions{
+		WebhookAddr:   "127.0.0.1:8991",
+		SigningSecret: secret,
+	})
+	go cli.runWebhook()
+	<-time.After(50 * time.Millisecond)
+	defer func() {
+		cli.shutdownWebhook <- struct{}{}
+		<-time.After(50 * time.Millisecond)
+	}()
+
+	body := []byte(testCallback)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	signature := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:8991", bytes.NewBuffer(body))
+	assert.Nil(err)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", signature)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, "http://127.0.0.1:8991", bytes.NewBuffer(body))
+	assert.Nil(err)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0=0000000000000000000000000000000000000000000000000000000000000000")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(err)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, "http://127.0.0.1:8991", bytes.NewBuffer(body))
+	assert.Nil(err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(err)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
 type clientBotMock struct {
 	sync.RWMutex
 	stopped  bool
-	actions  []slack.AttachmentActionCallback
+	actions  []AttachmentActionCallback
 	channels []string
 }
 
@@ -103,7 +159,7 @@ func (b *clientBotMock) stop() {
 	b.stopped = true
 }
 
-func (b *clientBotMock) handleAction(channel string, action slack.AttachmentActionCallback) {
+func (b *clientBotMock) handleAction(channel string, action AttachmentActionCallback) {
 	b.Lock()
 	defer b.Unlock()
 	b.channels = append(b.channels, channel)
@@ -159,18 +215,44 @@ func TestSetIntroHandler(t *testing.T) {
 	cli := newClient("", ClientOptions{})
 	ctrl := &helloCtrl{}
 	cli.SetIntroHandler(ctrl)
-	assert.Equal(t, reflect.ValueOf(ctrl).Pointer(), reflect.ValueOf(cli.introHandler).Pointer())
+	got, ok := cli.IntroHandler()
+	assert.True(t, ok)
+	assert.Equal(t, reflect.ValueOf(ctrl).Pointer(), reflect.ValueOf(got).Pointer())
 }
 
 func TestHandleIntro(t *testing.T) {
 	cli := newClient("", ClientOptions{})
 	ctrl := &helloCtrl{}
-	cli.HandleIntro(nil, flamingo.Channel{})
+	cli.HandleIntro(nil, flamingo.Channel{ID: "channel"})
 	cli.SetIntroHandler(ctrl)
-	cli.HandleIntro(nil, flamingo.Channel{})
+	cli.HandleIntro(nil, flamingo.Channel{ID: "channel"})
+	cli.HandleIntro(nil, flamingo.Channel{ID: "channel"})
 	assert.Equal(t, 1, ctrl.calledIntro)
 }
 
+// TestHandleIntroAcrossConversationKinds checks that intro dedup is keyed
+// by channel ID alone: a public channel, a private channel, an MPIM and an
+// IM each get their intro exactly once, independently of one another.
+func TestHandleIntroAcrossConversationKinds(t *testing.T) {
+	cli := newClient("", ClientOptions{})
+	ctrl := &helloCtrl{}
+	cli.SetIntroHandler(ctrl)
+
+	channels := []flamingo.Channel{
+		{ID: "C1", Kind: flamingo.ChannelPublic},
+		{ID: "C2", Kind: flamingo.ChannelPrivate},
+		{ID: "C3", Kind: flamingo.ChannelMPIM},
+		{ID: "C4", Kind: flamingo.ChannelIM},
+	}
+
+	for _, channel := range channels {
+		cli.HandleIntro(nil, channel)
+		cli.HandleIntro(nil, channel)
+	}
+
+	assert.Equal(t, len(channels), ctrl.calledIntro)
+}
+
 func newClient(token string, options ClientOptions) *slackClient {
 	options.Debug = true
 	return NewClient(token, options).(*slackClient)
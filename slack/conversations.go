@@ -0,0 +1,318 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mvader/flamingo"
+	"github.com/mvader/slack"
+)
+
+// conversationsPageSize is the page size requested for every paginated
+// conversations.* call.
+const conversationsPageSize = 200
+
+// rawConversation is just enough of the conversations.* JSON shape to
+// normalize a conversation into a flamingo.Channel. The pinned Slack client
+// predates the Conversations API, so there's no type for this in the
+// library at all; it's hand-rolled here the way AttachmentActionCallback is
+// hand-rolled in actions.go for a type the library doesn't have either.
+type rawConversation struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsIM      bool   `json:"is_im"`
+	IsMpim    bool   `json:"is_mpim"`
+	IsPrivate bool   `json:"is_private"`
+	// User is the peer's user id; only set for IMs.
+	User string `json:"user"`
+}
+
+// toChannel normalizes ch into a flamingo.Channel. IMs have no name of
+// their own; callers that need one should resolve it from User separately.
+func (ch rawConversation) toChannel() flamingo.Channel {
+	kind := flamingo.ChannelPublic
+	switch {
+	case ch.IsIM:
+		kind = flamingo.ChannelIM
+	case ch.IsMpim:
+		kind = flamingo.ChannelMPIM
+	case ch.IsPrivate:
+		kind = flamingo.ChannelPrivate
+	}
+	return flamingo.Channel{ID: ch.ID, Name: ch.Name, Kind: kind}
+}
+
+// rawMessage is just enough of a conversations.history/conversations.replies
+// message to normalize it into a flamingo.Message.
+type rawMessage struct {
+	User string `json:"user"`
+	Text string `json:"text"`
+}
+
+func toMessages(raw []rawMessage, channel string) []flamingo.Message {
+	msgs := make([]flamingo.Message, 0, len(raw))
+	for _, m := range raw {
+		msgs = append(msgs, flamingo.Message{Text: m.Text, User: m.User, Channel: channel})
+	}
+	return msgs
+}
+
+// responseMetadata carries the cursor a paginated conversations.* call
+// returns for its next page; an empty NextCursor means there isn't one.
+type responseMetadata struct {
+	NextCursor string `json:"next_cursor"`
+}
+
+// call posts values (plus the client's token) to the named conversations.*
+// Web API method and decodes its JSON response into out. The pinned Slack
+// client has no support for the Conversations API at all, so every
+// conversations.* method in this file calls it directly over HTTP rather
+// than through the library, following the same "hand-roll what the pinned
+// client is missing" approach actions.go uses for interactive messages.
+func (c *slackClient) call(method string, values url.Values, out interface{}) error {
+	values.Set("token", c.token)
+
+	resp, err := http.PostForm(slack.SLACK_API+method, values)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// forEachPage drives a cursor-paginated conversations.* call: it invokes
+// fetch once per page, with the cursor fetch itself returned for the next
+// one, stopping once that comes back empty or fetch returns an error.
+func forEachPage(fetch func(cursor string) (nextCursor string, err error)) error {
+	cursor := ""
+	for {
+		next, err := fetch(cursor)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+type conversationsListResponse struct {
+	OK               bool              `json:"ok"`
+	Error            string            `json:"error"`
+	Channels         []rawConversation `json:"channels"`
+	ResponseMetadata responseMetadata  `json:"response_metadata"`
+}
+
+func (c *slackClient) conversationsList(cursor string) (conversationsListResponse, error) {
+	values := url.Values{
+		"limit": {strconv.Itoa(conversationsPageSize)},
+		"types": {"public_channel,private_channel,mpim,im"},
+	}
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	var resp conversationsListResponse
+	if err := c.call("conversations.list", values, &resp); err != nil {
+		return conversationsListResponse{}, err
+	}
+	if !resp.OK {
+		return conversationsListResponse{}, fmt.Errorf("slack: conversations.list: %s", resp.Error)
+	}
+	return resp, nil
+}
+
+// Conversations returns every conversation the bot is part of — public
+// channels, private channels, MPIMs and IMs alike — normalized into
+// flamingo.Channel via conversations.list, following its cursor to collect
+// every page. IM names are resolved with a single batched users.list call
+// rather than one users.info call per DM, so startup cost doesn't grow
+// with the number of IMs.
+func (c *slackClient) Conversations() ([]flamingo.Channel, error) {
+	var raw []rawConversation
+	err := forEachPage(func(cursor string) (string, error) {
+		resp, err := c.conversationsList(cursor)
+		if err != nil {
+			return "", err
+		}
+		raw = append(raw, resp.Channels...)
+		return resp.ResponseMetadata.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var imUserIDs []string
+	for _, ch := range raw {
+		if ch.IsIM {
+			imUserIDs = append(imUserIDs, ch.User)
+		}
+	}
+	names, err := batchUserNames(slack.New(c.token), imUserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]flamingo.Channel, 0, len(raw))
+	for _, ch := range raw {
+		channel := ch.toChannel()
+		if ch.IsIM {
+			channel.Name = names[ch.User]
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// ConversationInfo fetches and normalizes a single conversation via
+// conversations.info, which works the same way regardless of the
+// conversation's kind.
+func (c *slackClient) ConversationInfo(id string) (flamingo.Channel, error) {
+	var resp struct {
+		OK      bool            `json:"ok"`
+		Error   string          `json:"error"`
+		Channel rawConversation `json:"channel"`
+	}
+	if err := c.call("conversations.info", url.Values{"channel": {id}}, &resp); err != nil {
+		return flamingo.Channel{}, err
+	}
+	if !resp.OK {
+		return flamingo.Channel{}, fmt.Errorf("slack: conversations.info: %s", resp.Error)
+	}
+
+	channel := resp.Channel.toChannel()
+	if resp.Channel.IsIM && resp.Channel.User != "" {
+		user, err := slack.New(c.token).GetUserInfo(resp.Channel.User)
+		if err != nil {
+			return flamingo.Channel{}, err
+		}
+		channel.Name = user.Name
+	}
+	return channel, nil
+}
+
+// ConversationMembers returns the IDs of every member of channel via
+// conversations.members, following its cursor to collect every page.
+func (c *slackClient) ConversationMembers(channel string) ([]string, error) {
+	var members []string
+	err := forEachPage(func(cursor string) (string, error) {
+		values := url.Values{
+			"channel": {channel},
+			"limit":   {strconv.Itoa(conversationsPageSize)},
+		}
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+
+		var resp struct {
+			OK               bool             `json:"ok"`
+			Error            string           `json:"error"`
+			Members          []string         `json:"members"`
+			ResponseMetadata responseMetadata `json:"response_metadata"`
+		}
+		if err := c.call("conversations.members", values, &resp); err != nil {
+			return "", err
+		}
+		if !resp.OK {
+			return "", fmt.Errorf("slack: conversations.members: %s", resp.Error)
+		}
+		members = append(members, resp.Members...)
+		return resp.ResponseMetadata.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ConversationHistory returns the most recent messages posted to channel
+// via conversations.history.
+func (c *slackClient) ConversationHistory(channel string) ([]flamingo.Message, error) {
+	values := url.Values{
+		"channel": {channel},
+		"limit":   {strconv.Itoa(conversationsPageSize)},
+	}
+
+	var resp struct {
+		OK       bool         `json:"ok"`
+		Error    string       `json:"error"`
+		Messages []rawMessage `json:"messages"`
+	}
+	if err := c.call("conversations.history", values, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("slack: conversations.history: %s", resp.Error)
+	}
+	return toMessages(resp.Messages, channel), nil
+}
+
+// ConversationReplies returns every reply in the thread rooted at threadTS
+// within channel (including the parent message, which conversations.replies
+// returns as its first result) via conversations.replies, following its
+// cursor to collect every page.
+func (c *slackClient) ConversationReplies(channel, threadTS string) ([]flamingo.Message, error) {
+	var raw []rawMessage
+	err := forEachPage(func(cursor string) (string, error) {
+		values := url.Values{
+			"channel": {channel},
+			"ts":      {threadTS},
+			"limit":   {strconv.Itoa(conversationsPageSize)},
+		}
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+
+		var resp struct {
+			OK               bool             `json:"ok"`
+			Error            string           `json:"error"`
+			Messages         []rawMessage     `json:"messages"`
+			ResponseMetadata responseMetadata `json:"response_metadata"`
+		}
+		if err := c.call("conversations.replies", values, &resp); err != nil {
+			return "", err
+		}
+		if !resp.OK {
+			return "", fmt.Errorf("slack: conversations.replies: %s", resp.Error)
+		}
+		raw = append(raw, resp.Messages...)
+		return resp.ResponseMetadata.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toMessages(raw, channel), nil
+}
+
+// batchUserNames resolves a display name for each of userIDs with a single
+// users.list call, instead of one users.info call per id — the distinction
+// that matters when resolving IM peers on startup, where the naive
+// approach is one call per DM.
+func batchUserNames(api *slack.Slack, userIDs []string) (map[string]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	users, err := api.GetUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	names := make(map[string]string, len(userIDs))
+	for _, u := range users {
+		if wanted[u.Id] {
+			names[u.Id] = u.Name
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,230 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mvader/flamingo"
+	"github.com/mvader/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawConversationToChannel(t *testing.T) {
+	assert := assert.New(t)
+
+	ch := rawConversation{ID: "C123", Name: "general"}
+	assert.Equal(flamingo.Channel{ID: "C123", Name: "general", Kind: flamingo.ChannelPublic}, ch.toChannel())
+
+	ch = rawConversation{ID: "G123", Name: "secret-project", IsPrivate: true}
+	assert.Equal(flamingo.ChannelPrivate, ch.toChannel().Kind)
+
+	ch = rawConversation{ID: "G456", Name: "mpdm-alice--bob--carol-1", IsPrivate: true, IsMpim: true}
+	assert.Equal(flamingo.ChannelMPIM, ch.toChannel().Kind)
+
+	ch = rawConversation{ID: "D123", IsIM: true, User: "U123"}
+	im := ch.toChannel()
+	assert.Equal(flamingo.ChannelIM, im.Kind)
+	assert.Equal("", im.Name)
+}
+
+func TestBatchUserNamesEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	names, err := batchUserNames(slack.New(""), nil)
+	assert.Nil(err)
+	assert.Nil(names)
+}
+
+// conversationsFakeServer serves the conversations.*/users.* endpoints this
+// file needs, driven by the handlers given per path.
+func conversationsFakeServer(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+		handler(w, r)
+	}))
+
+	origAPI := slack.SLACK_API
+	slack.SLACK_API = server.URL + "/"
+	t.Cleanup(func() {
+		slack.SLACK_API = origAPI
+		server.Close()
+	})
+	return server
+}
+
+func TestConversationsFollowsCursorAndBatchesIMNames(t *testing.T) {
+	assert := assert.New(t)
+
+	var listCalls int
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.list": func(w http.ResponseWriter, r *http.Request) {
+			listCalls++
+			if r.FormValue("cursor") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"ok": true,
+					"channels": []map[string]interface{}{
+						{"id": "C1", "name": "general"},
+					},
+					"response_metadata": map[string]string{"next_cursor": "page2"},
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"channels": []map[string]interface{}{
+					{"id": "D1", "is_im": true, "user": "U1"},
+				},
+				"response_metadata": map[string]string{"next_cursor": ""},
+			})
+		},
+		"users.list": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"members": []map[string]interface{}{
+					{"id": "U1", "name": "alice"},
+				},
+			})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	channels, err := cli.Conversations()
+	assert.Nil(err)
+	assert.Equal(2, listCalls)
+	assert.Equal([]flamingo.Channel{
+		{ID: "C1", Name: "general", Kind: flamingo.ChannelPublic},
+		{ID: "D1", Name: "alice", Kind: flamingo.ChannelIM},
+	}, channels)
+}
+
+func TestConversationInfoResolvesIMPeerName(t *testing.T) {
+	assert := assert.New(t)
+
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.info": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"channel": map[string]interface{}{
+					"id": "D1", "is_im": true, "user": "U1",
+				},
+			})
+		},
+		"users.info": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("U1", r.FormValue("user"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok":   true,
+				"user": map[string]interface{}{"id": "U1", "name": "alice"},
+			})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	channel, err := cli.ConversationInfo("D1")
+	assert.Nil(err)
+	assert.Equal(flamingo.Channel{ID: "D1", Name: "alice", Kind: flamingo.ChannelIM}, channel)
+}
+
+func TestConversationInfoError(t *testing.T) {
+	assert := assert.New(t)
+
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.info": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	_, err := cli.ConversationInfo("C404")
+	assert.EqualError(err, "slack: conversations.info: channel_not_found")
+}
+
+func TestConversationMembersFollowsCursor(t *testing.T) {
+	assert := assert.New(t)
+
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.members": func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("cursor") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"ok":                true,
+					"members":           []string{"U1", "U2"},
+					"response_metadata": map[string]string{"next_cursor": "page2"},
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok":                true,
+				"members":           []string{"U3"},
+				"response_metadata": map[string]string{"next_cursor": ""},
+			})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	members, err := cli.ConversationMembers("C1")
+	assert.Nil(err)
+	assert.Equal([]string{"U1", "U2", "U3"}, members)
+}
+
+func TestConversationHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("C1", r.FormValue("channel"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"messages": []map[string]interface{}{
+					{"user": "U1", "text": "hi"},
+				},
+			})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	msgs, err := cli.ConversationHistory("C1")
+	assert.Nil(err)
+	assert.Equal([]flamingo.Message{{Text: "hi", User: "U1", Channel: "C1"}}, msgs)
+}
+
+func TestConversationRepliesFollowsCursor(t *testing.T) {
+	assert := assert.New(t)
+
+	conversationsFakeServer(t, map[string]http.HandlerFunc{
+		"conversations.replies": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("1234.5678", r.FormValue("ts"))
+			if r.FormValue("cursor") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"ok": true,
+					"messages": []map[string]interface{}{
+						{"user": "U1", "text": "parent"},
+					},
+					"response_metadata": map[string]string{"next_cursor": "page2"},
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"messages": []map[string]interface{}{
+					{"user": "U2", "text": "reply"},
+				},
+				"response_metadata": map[string]string{"next_cursor": ""},
+			})
+		},
+	})
+
+	cli := newClient("token", ClientOptions{})
+	msgs, err := cli.ConversationReplies("C123", "1234.5678")
+	assert.Nil(err)
+	assert.Equal([]flamingo.Message{
+		{Text: "parent", User: "U1", Channel: "C123"},
+		{Text: "reply", User: "U2", Channel: "C123"},
+	}, msgs)
+}
@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/mvader/flamingo"
+)
+
+// Event is a single Events API event, e.g. "message", "app_mention",
+// "reaction_added", "member_joined_channel" or "channel_created". Raw holds
+// the full, untouched event JSON for handlers that need fields this type
+// doesn't surface.
+type Event struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel"`
+	User    string          `json:"user"`
+	Text    string          `json:"text"`
+	Raw     json.RawMessage `json:"-"`
+}
+
+// EventHandler handles a single Events API event.
+type EventHandler func(bot flamingo.Bot, event Event)
+
+// SlashCommand is a single slash command invocation.
+type SlashCommand struct {
+	Command     string
+	Text        string
+	ChannelID   string
+	UserID      string
+	ResponseURL string
+}
+
+// SlashResponse is the (optional) immediate reply to a slash command.
+type SlashResponse struct {
+	Text         string `json:"text"`
+	ResponseType string `json:"response_type,omitempty"`
+}
+
+// SlashHandler handles a slash command, optionally returning a response to
+// send back immediately.
+type SlashHandler func(bot flamingo.Bot, cmd SlashCommand) (*SlashResponse, error)
+
+// AddEventHandler registers handler to run for every Events API event of
+// the given type.
+func (c *slackClient) AddEventHandler(eventType string, handler EventHandler) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.eventHandlers[eventType] = handler
+}
+
+// AddSlashCommandHandler registers handler for the given slash command,
+// e.g. "/deploy".
+func (c *slackClient) AddSlashCommandHandler(command string, handler SlashHandler) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.slashHandlers[command] = handler
+}
+
+type urlVerification struct {
+	Challenge string `json:"challenge"`
+}
+
+func (c *slackClient) handleURLVerification(w http.ResponseWriter, body []byte) {
+	var v urlVerification
+	if err := json.Unmarshal(body, &v); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(urlVerification{Challenge: v.Challenge})
+}
+
+type eventCallback struct {
+	Event json.RawMessage `json:"event"`
+}
+
+func (c *slackClient) handleEventCallback(ctx context.Context, w http.ResponseWriter, body []byte) {
+	var env eventCallback
+	if err := json.Unmarshal(body, &env); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(env.Event, &event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	event.Raw = env.Event
+
+	c.mut.RLock()
+	handler, ok := c.eventHandlers[event.Type]
+	c.mut.RUnlock()
+
+	// Always acknowledge immediately: Slack retries event_callback
+	// deliveries that aren't answered within three seconds.
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	if !ok {
+		return
+	}
+
+	chain := c.ChainFor(event.Type, func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+		handler(bot, payload.(Event))
+		return nil
+	})
+	sb := &slackBot{client: c, channel: event.Channel, user: event.User}
+	_ = chain(ctx, sb, event)
+}
+
+func (c *slackClient) handleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !c.verifyRequest(r, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cmd := SlashCommand{
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		ChannelID:   form.Get("channel_id"),
+		UserID:      form.Get("user_id"),
+		ResponseURL: form.Get("response_url"),
+	}
+
+	c.mut.RLock()
+	handler, ok := c.slashHandlers[cmd.Command]
+	c.mut.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var resp *SlashResponse
+	chain := c.ChainFor(cmd.Command, func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+		var err error
+		resp, err = handler(bot, payload.(SlashCommand))
+		return err
+	})
+	sb := &slackBot{client: c, channel: cmd.ChannelID, user: cmd.UserID}
+	if err := chain(r.Context(), sb, cmd); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,84 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mvader/flamingo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventCallback(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{WebhookAddr: "127.0.0.1:8992"})
+
+	var mut sync.Mutex
+	var got flamingo.Message
+	cli.AddEventHandler("message", func(bot flamingo.Bot, event Event) {
+		mut.Lock()
+		defer mut.Unlock()
+		got = flamingo.Message{Text: event.Text, Channel: event.Channel, User: event.User}
+	})
+
+	go cli.runWebhook()
+	<-time.After(50 * time.Millisecond)
+	defer func() {
+		cli.shutdownWebhook <- struct{}{}
+		<-time.After(50 * time.Millisecond)
+	}()
+
+	body := `{
+		"type": "event_callback",
+		"token": "bot",
+		"event": {"type": "message", "channel": "channel", "user": "user", "text": "hello"}
+	}`
+	resp, err := http.Post("http://127.0.0.1:8992", "application/json", bytes.NewBuffer([]byte(body)))
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	<-time.After(10 * time.Millisecond)
+
+	mut.Lock()
+	defer mut.Unlock()
+	assert.Equal("hello", got.Text)
+	assert.Equal("channel", got.Channel)
+	assert.Equal("user", got.User)
+}
+
+func TestSlashCommand(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{
+		WebhookAddr:  "127.0.0.1:8993",
+		CommandsPath: "/commands",
+	})
+
+	cli.AddSlashCommandHandler("/deploy", func(bot flamingo.Bot, cmd SlashCommand) (*SlashResponse, error) {
+		return &SlashResponse{Text: "deploying " + cmd.Text}, nil
+	})
+
+	go cli.runWebhook()
+	<-time.After(50 * time.Millisecond)
+	defer func() {
+		cli.shutdownWebhook <- struct{}{}
+		<-time.After(50 * time.Millisecond)
+	}()
+
+	form := url.Values{
+		"command":    {"/deploy"},
+		"text":       {"staging"},
+		"channel_id": {"channel"},
+		"user_id":    {"user"},
+	}
+	resp, err := http.Post("http://127.0.0.1:8993/commands", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	var slashResp SlashResponse
+	assert.Nil(json.NewDecoder(resp.Body).Decode(&slashResp))
+	assert.Equal("deploying staging", slashResp.Text)
+}
@@ -0,0 +1,113 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mvader/flamingo"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordingMiddleware(name string, calls *[]string) flamingo.Middleware {
+	return func(next flamingo.HandlerFunc) flamingo.HandlerFunc {
+		return func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+			*calls = append(*calls, name)
+			return next(ctx, bot, payload)
+		}
+	}
+}
+
+func shortCircuitMiddleware(calls *[]string) flamingo.Middleware {
+	return func(next flamingo.HandlerFunc) flamingo.HandlerFunc {
+		return func(ctx context.Context, bot flamingo.Bot, payload interface{}) error {
+			*calls = append(*calls, "short-circuit")
+			return nil
+		}
+	}
+}
+
+func TestMiddlewareOrdering(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{})
+
+	var calls []string
+	cli.Use(recordingMiddleware("one", &calls), recordingMiddleware("two", &calls))
+	cli.UseFor("hello", recordingMiddleware("three", &calls))
+
+	ctrl := &helloCtrl{}
+	cli.AddController(ctrl)
+
+	err := cli.DispatchMessage(context.Background(), nil, flamingo.Message{Text: "hello"})
+	assert.Nil(err)
+	assert.Equal([]string{"one", "two"}, calls)
+	assert.Equal(1, len(ctrl.msgs))
+}
+
+func TestMiddlewareUseForMatchesControllerID(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{})
+
+	var calls []string
+	cli.UseFor("hello-id", recordingMiddleware("scoped", &calls))
+
+	ctrl := &identifiableHelloCtrl{id: "hello-id"}
+	cli.AddController(ctrl)
+
+	assert.Nil(cli.DispatchMessage(context.Background(), nil, flamingo.Message{Text: "hello"}))
+	assert.Equal([]string{"scoped"}, calls)
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{})
+
+	var calls []string
+	cli.Use(shortCircuitMiddleware(&calls))
+
+	ctrl := &helloCtrl{}
+	cli.AddController(ctrl)
+
+	err := cli.DispatchMessage(context.Background(), nil, flamingo.Message{Text: "hello"})
+	assert.Nil(err)
+	assert.Equal([]string{"short-circuit"}, calls)
+	assert.Equal(0, len(ctrl.msgs))
+}
+
+func TestMiddlewareThroughWebhook(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("xAB3yVzGS4BQ3O9FACTa8Ho4", ClientOptions{
+		WebhookAddr: "127.0.0.1:8990",
+	})
+
+	var calls []string
+	cli.Use(recordingMiddleware("webhook-global", &calls))
+	cli.UseFor("test_callback", recordingMiddleware("webhook-scoped", &calls))
+
+	var got flamingo.Action
+	cli.AddActionHandler("test_callback", func(bot flamingo.Bot, action flamingo.Action) {
+		got = action
+	})
+
+	go cli.runWebhook()
+	<-time.After(50 * time.Millisecond)
+
+	resp, err := http.Post("http://127.0.0.1:8990", "application/json", bytes.NewBuffer([]byte(testCallback)))
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	cli.shutdownWebhook <- struct{}{}
+	<-time.After(50 * time.Millisecond)
+
+	assert.Equal([]string{"webhook-global", "webhook-scoped"}, calls)
+	assert.Equal("test_callback", got.CallbackID)
+}
+
+type identifiableHelloCtrl struct {
+	helloCtrl
+	id string
+}
+
+func (c *identifiableHelloCtrl) ID() string { return c.id }
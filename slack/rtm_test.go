@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"encoding/json"
+	"testing"
+
+	"github.com/mvader/slack"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// TestRunEstablishesRTMConnection exercises the real RTM path end to end:
+// Run, with EnableRTM set, calls rtm.start against a fake Web API server,
+// dials the websocket URL it returns, and dispatches an ordinary chat
+// message pushed down that connection to a registered controller.
+func TestRunEstablishesRTMConnection(t *testing.T) {
+	assert := assert.New(t)
+
+	wsServer := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		websocket.JSON.Send(ws, map[string]interface{}{
+			"type":    "message",
+			"channel": "channel",
+			"user":    "user",
+			"text":    "hello",
+		})
+		// Keep the connection open so HandleIncomingEvents doesn't spin
+		// reconnecting for the rest of the test.
+		<-time.After(time.Second)
+	}))
+	defer wsServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(wsServer.URL, "http")
+
+	restServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":  true,
+			"url": wsURL,
+		})
+	}))
+	defer restServer.Close()
+
+	origAPI := slack.SLACK_API
+	slack.SLACK_API = restServer.URL + "/"
+	defer func() { slack.SLACK_API = origAPI }()
+
+	cli := newClient("token", ClientOptions{EnableRTM: true})
+	ctrl := &helloCtrl{}
+	cli.AddController(ctrl)
+
+	go cli.Run()
+	defer cli.Stop()
+
+	assert.Eventually(func() bool {
+		ctrl.RLock()
+		defer ctrl.RUnlock()
+		return len(ctrl.msgs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	ctrl.RLock()
+	defer ctrl.RUnlock()
+	assert.Equal("hello", ctrl.msgs[0].Text)
+	assert.Equal("channel", ctrl.msgs[0].Channel)
+	assert.Equal("user", ctrl.msgs[0].User)
+}
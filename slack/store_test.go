@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mvader/flamingo"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionCtrl only claims the first message of a conversation ("start").
+// Once dispatched once, subsequent messages in the same channel should
+// keep going to it via conversation ownership, regardless of CanHandle.
+type sessionCtrl struct {
+	id   string
+	msgs []flamingo.Message
+}
+
+func (c *sessionCtrl) ID() string { return c.id }
+
+func (c *sessionCtrl) CanHandle(msg flamingo.Message) bool {
+	return msg.Text == "start"
+}
+
+func (c *sessionCtrl) Handle(bot flamingo.Bot, msg flamingo.Message) error {
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+func TestConversationOwnershipKeepsFollowUpMessagesWithTheSameController(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{})
+	ctrl := &sessionCtrl{id: "session"}
+	cli.AddController(ctrl)
+
+	assert.Nil(cli.DispatchMessage(context.Background(), nil, flamingo.Message{Channel: "channel", Text: "start"}))
+	assert.Nil(cli.DispatchMessage(context.Background(), nil, flamingo.Message{Channel: "channel", Text: "anything else"}))
+	assert.Equal(2, len(ctrl.msgs))
+}
+
+func TestConversationOwnershipSurvivesRestart(t *testing.T) {
+	assert := assert.New(t)
+	store := flamingo.NewMemoryStore()
+
+	first := newClient("", ClientOptions{Store: store})
+	ctrl := &sessionCtrl{id: "session"}
+	first.AddController(ctrl)
+	assert.Nil(first.DispatchMessage(context.Background(), nil, flamingo.Message{Channel: "channel", Text: "start"}))
+
+	// A new client sharing the same store picks up where the discarded
+	// one left off: the message below would not match CanHandle on its
+	// own, so only ownership carried over via the store can route it.
+	second := newClient("", ClientOptions{Store: store})
+	ctrl2 := &sessionCtrl{id: "session"}
+	second.AddController(ctrl2)
+	assert.Nil(second.DispatchMessage(context.Background(), nil, flamingo.Message{Channel: "channel", Text: "anything else"}))
+
+	assert.Equal(1, len(ctrl.msgs))
+	assert.Equal(1, len(ctrl2.msgs))
+}
+
+func TestBotState(t *testing.T) {
+	assert := assert.New(t)
+	cli := newClient("", ClientOptions{})
+	bot := &slackBot{client: cli, channel: "channel", user: "user"}
+
+	_, ok, err := bot.State("key")
+	assert.Nil(err)
+	assert.False(ok)
+
+	assert.Nil(bot.SetState("key", "value", 0))
+	value, ok, err := bot.State("key")
+	assert.Nil(err)
+	assert.True(ok)
+	assert.Equal("value", value)
+}
@@ -0,0 +1,20 @@
+package slack
+
+// testCallback is a minimal AttachmentActionCallback payload, as Slack posts
+// it to the interactive-message webhook, used by the Run/Stop and webhook
+// tests below.
+const testCallback = `{
+	"token": "bot",
+	"callback_id": "test_callback",
+	"channel": {"id": "channel", "name": "general"},
+	"user": {"id": "user", "name": "bob"},
+	"actions": [{"name": "confirm", "value": "yes"}]
+}`
+
+// testURLVerification is the handshake Slack sends when an Events API
+// subscription URL is first configured.
+const testURLVerification = `{
+	"type": "url_verification",
+	"token": "bot",
+	"challenge": "a_challenge_value"
+}`
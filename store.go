@@ -0,0 +1,103 @@
+package flamingo
+
+import (
+	"sync"
+	"time"
+)
+
+// ConversationStore persists state that needs to survive process restarts:
+// which controller owns a channel's conversation between turns, whether a
+// channel's intro has already fired, and per-user scratch state set via
+// Bot.SetState. Entries are addressed by channel, user and key; channel or
+// user may be empty for state that isn't scoped to one of them.
+type ConversationStore interface {
+	// Get returns the value stored under channel, user and key, and
+	// whether it was found. A missing or expired entry returns ("", false,
+	// nil).
+	Get(channel, user, key string) (string, bool, error)
+	// Set stores value under channel, user and key. A zero ttl means the
+	// entry never expires.
+	Set(channel, user, key, value string, ttl time.Duration) error
+	// Delete removes the value stored under channel, user and key, if any.
+	Delete(channel, user, key string) error
+	// Scan returns every non-expired key/value pair stored under channel
+	// and user.
+	Scan(channel, user string) (map[string]string, error)
+}
+
+type storeEntry struct {
+	value   string
+	expires time.Time
+	hasTTL  bool
+}
+
+func (e storeEntry) expired(now time.Time) bool {
+	return e.hasTTL && !e.expires.After(now)
+}
+
+// memoryStore is a ConversationStore backed by a map. It is the default
+// store a Backend uses when none is configured; state does not survive a
+// process restart.
+type memoryStore struct {
+	mut     sync.RWMutex
+	entries map[string]map[string]storeEntry
+}
+
+// NewMemoryStore creates an in-memory ConversationStore.
+func NewMemoryStore() ConversationStore {
+	return &memoryStore{entries: make(map[string]map[string]storeEntry)}
+}
+
+func storeScope(channel, user string) string {
+	return channel + "\x00" + user
+}
+
+func (s *memoryStore) Get(channel, user, key string) (string, bool, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	entry, ok := s.entries[storeScope(channel, user)][key]
+	if !ok || entry.expired(time.Now()) {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *memoryStore) Set(channel, user, key, value string, ttl time.Duration) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	scope := storeScope(channel, user)
+	if s.entries[scope] == nil {
+		s.entries[scope] = make(map[string]storeEntry)
+	}
+
+	entry := storeEntry{value: value}
+	if ttl > 0 {
+		entry.hasTTL = true
+		entry.expires = time.Now().Add(ttl)
+	}
+	s.entries[scope][key] = entry
+	return nil
+}
+
+func (s *memoryStore) Delete(channel, user, key string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.entries[storeScope(channel, user)], key)
+	return nil
+}
+
+func (s *memoryStore) Scan(channel, user string) (map[string]string, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	now := time.Now()
+	result := make(map[string]string)
+	for key, entry := range s.entries[storeScope(channel, user)] {
+		if !entry.expired(now) {
+			result[key] = entry.value
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,270 @@
+// Package telegram implements a flamingo.Backend backed by the Telegram Bot
+// API, using long polling to receive updates.
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mvader/flamingo"
+)
+
+const defaultBaseURL = "https://api.telegram.org"
+
+// ownerStateKey namespaces this backend's conversation-ownership
+// bookkeeping within a ConversationStore that might be shared with other
+// backends.
+const ownerStateKey = "telegram.owner"
+
+// ClientOptions configures a telegram client.
+type ClientOptions struct {
+	// PollTimeout is the long-poll timeout passed to getUpdates. Defaults
+	// to 30 seconds.
+	PollTimeout time.Duration
+	// HTTPClient is used to talk to the Bot API. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// BaseURL overrides the Bot API base URL, for tests.
+	BaseURL string
+	// Debug turns on verbose logging of received updates.
+	Debug bool
+}
+
+type telegramClient struct {
+	mut sync.RWMutex
+
+	token   string
+	baseURL string
+	http    *http.Client
+	options ClientOptions
+
+	*flamingo.Dispatcher
+
+	introSeen map[int64]bool
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewClient creates a flamingo.Backend that talks to Telegram. token is a
+// bot token obtained from @BotFather.
+func NewClient(token string, options ClientOptions) flamingo.Backend {
+	if options.PollTimeout == 0 {
+		options.PollTimeout = 30 * time.Second
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &telegramClient{
+		token:      token,
+		baseURL:    baseURL,
+		http:       options.HTTPClient,
+		options:    options,
+		Dispatcher: flamingo.NewDispatcher(nil, ownerStateKey),
+		introSeen:  make(map[int64]bool),
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+}
+
+func (c *telegramClient) HandleIntro(b flamingo.Bot, channel flamingo.Channel) error {
+	h, ok := c.IntroHandler()
+	if !ok {
+		return nil
+	}
+
+	chain := c.ChainFor("", func(ctx context.Context, b flamingo.Bot, payload interface{}) error {
+		return h.HandleIntro(b, payload.(flamingo.Channel))
+	})
+	return chain(context.Background(), b, channel)
+}
+
+func (c *telegramClient) apiURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+}
+
+// Run starts long-polling for updates and blocks until Stop is called.
+func (c *telegramClient) Run() error {
+	offset := 0
+	for {
+		select {
+		case <-c.stop:
+			close(c.stopped)
+			return nil
+		default:
+		}
+
+		updates, err := c.getUpdates(offset)
+		if err != nil {
+			if c.options.Debug {
+				fmt.Printf("telegram: getUpdates: %v\n", err)
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, u := range updates {
+			if c.options.Debug {
+				fmt.Printf("telegram: received update %d\n", u.UpdateID)
+			}
+			offset = u.UpdateID + 1
+			c.handleUpdate(u)
+		}
+	}
+}
+
+// Stop stops the polling loop.
+func (c *telegramClient) Stop() error {
+	close(c.stop)
+	<-c.stopped
+	return nil
+}
+
+// Send posts a text message to chatID via sendMessage.
+func (c *telegramClient) Send(chatID string, msg flamingo.OutgoingMessage) error {
+	form := url.Values{
+		"chat_id": {chatID},
+		"text":    {msg.Text},
+	}
+	if markup, ok := msg.Extra["reply_markup"].(string); ok {
+		form.Set("reply_markup", markup)
+	}
+
+	resp, err := c.http.PostForm(c.apiURL("sendMessage"), form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram: sendMessage: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *telegramClient) getUpdates(offset int) ([]update, error) {
+	form := url.Values{
+		"offset":  {strconv.Itoa(offset)},
+		"timeout": {strconv.Itoa(int(c.options.PollTimeout / time.Second))},
+	}
+
+	resp, err := c.http.PostForm(c.apiURL("getUpdates"), form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OK     bool     `json:"ok"`
+		Result []update `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if !body.OK {
+		return nil, fmt.Errorf("telegram: getUpdates returned ok=false")
+	}
+	return body.Result, nil
+}
+
+func (c *telegramClient) handleUpdate(u update) {
+	ctx := context.Background()
+
+	switch {
+	case u.Message != nil && len(u.Message.NewChatMembers) > 0:
+		chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+		channel := flamingo.Channel{ID: chatID, Name: u.Message.Chat.Title}
+		bot := &telegramBot{client: c, channel: chatID}
+		c.mut.Lock()
+		already := c.introSeen[u.Message.Chat.ID]
+		c.introSeen[u.Message.Chat.ID] = true
+		c.mut.Unlock()
+		if !already {
+			_ = c.HandleIntro(bot, channel)
+		}
+
+	case u.Message != nil:
+		chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+		userID := strconv.FormatInt(u.Message.From.ID, 10)
+		msg := flamingo.Message{Text: u.Message.Text, User: userID, Channel: chatID}
+		bot := &telegramBot{client: c, channel: chatID, user: userID}
+		_ = c.DispatchMessage(ctx, bot, msg)
+
+	case u.CallbackQuery != nil:
+		chatID := strconv.FormatInt(u.CallbackQuery.Message.Chat.ID, 10)
+		userID := strconv.FormatInt(u.CallbackQuery.From.ID, 10)
+		action := flamingo.Action{
+			CallbackID: u.CallbackQuery.Data,
+			Channel:    chatID,
+			User:       userID,
+			Value:      u.CallbackQuery.Data,
+		}
+		bot := &telegramBot{client: c, channel: chatID, user: userID}
+		_ = c.DispatchAction(ctx, bot, action.CallbackID, action)
+	}
+}
+
+// telegramBot is the flamingo.Bot given to controllers handling Telegram
+// updates. channel and user scope its State/SetState calls to the
+// conversation the update is happening in.
+type telegramBot struct {
+	client  *telegramClient
+	channel string
+	user    string
+}
+
+func (b *telegramBot) Reply(msg flamingo.Message, text string) error {
+	return b.client.Send(msg.Channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *telegramBot) Say(channel, text string) error {
+	return b.client.Send(channel, flamingo.OutgoingMessage{Text: text})
+}
+
+func (b *telegramBot) State(key string) (string, bool, error) {
+	return b.client.Store().Get(b.channel, b.user, key)
+}
+
+func (b *telegramBot) SetState(key, value string, ttl time.Duration) error {
+	return b.client.Store().Set(b.channel, b.user, key, value, ttl)
+}
+
+// update, message, chat, user and callbackQuery mirror just the fields of
+// the Telegram Bot API types flamingo needs.
+type update struct {
+	UpdateID      int            `json:"update_id"`
+	Message       *message       `json:"message"`
+	CallbackQuery *callbackQuery `json:"callback_query"`
+}
+
+type message struct {
+	Text           string `json:"text"`
+	From           user   `json:"from"`
+	Chat           chat   `json:"chat"`
+	NewChatMembers []user `json:"new_chat_members"`
+}
+
+type chat struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type user struct {
+	ID int64 `json:"id"`
+}
+
+type callbackQuery struct {
+	Data    string  `json:"data"`
+	From    user    `json:"from"`
+	Message message `json:"message"`
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mvader/flamingo"
+	"github.com/stretchr/testify/assert"
+)
+
+type helloCtrl struct {
+	msgs []flamingo.Message
+}
+
+func (c *helloCtrl) CanHandle(msg flamingo.Message) bool { return msg.Text == "hello" }
+
+func (c *helloCtrl) Handle(bot flamingo.Bot, msg flamingo.Message) error {
+	c.msgs = append(c.msgs, msg)
+	return nil
+}
+
+// fakeBotAPI serves just enough of the Bot API for the client to long-poll
+// a single batch of updates and then idle.
+func fakeBotAPI(t *testing.T, updates []update) *httptest.Server {
+	served := false
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if served {
+			w.Write([]byte(`{"ok":true,"result":[]}`))
+			return
+		}
+		served = true
+		body := struct {
+			OK     bool     `json:"ok"`
+			Result []update `json:"result"`
+		}{OK: true, Result: updates}
+		assert.Nil(t, json.NewEncoder(w).Encode(body))
+	}))
+}
+
+func TestHandleUpdateDispatchesMessage(t *testing.T) {
+	server := fakeBotAPI(t, []update{
+		{UpdateID: 1, Message: &message{Text: "hello", From: user{ID: 42}, Chat: chat{ID: 7}}},
+	})
+	defer server.Close()
+
+	cli := NewClient("token", ClientOptions{BaseURL: server.URL}).(*telegramClient)
+	ctrl := &helloCtrl{}
+	cli.AddController(ctrl)
+
+	go cli.Run()
+	<-time.After(50 * time.Millisecond)
+	assert.Nil(t, cli.Stop())
+
+	assert.Equal(t, 1, len(ctrl.msgs))
+	assert.Equal(t, "hello", ctrl.msgs[0].Text)
+	assert.Equal(t, "7", ctrl.msgs[0].Channel)
+	assert.Equal(t, "42", ctrl.msgs[0].User)
+}
+
+func TestHandleUpdateFiresIntroOnce(t *testing.T) {
+	server := fakeBotAPI(t, []update{
+		{UpdateID: 1, Message: &message{Chat: chat{ID: 9}, NewChatMembers: []user{{ID: 1}}}},
+	})
+	defer server.Close()
+
+	cli := NewClient("token", ClientOptions{BaseURL: server.URL}).(*telegramClient)
+	var intros int
+	cli.SetIntroHandler(introHandlerFunc(func(bot flamingo.Bot, channel flamingo.Channel) error {
+		intros++
+		return nil
+	}))
+
+	go cli.Run()
+	<-time.After(50 * time.Millisecond)
+	assert.Nil(t, cli.Stop())
+
+	assert.Equal(t, 1, intros)
+	assert.True(t, cli.introSeen[9])
+}
+
+type introHandlerFunc func(bot flamingo.Bot, channel flamingo.Channel) error
+
+func (f introHandlerFunc) HandleIntro(bot flamingo.Bot, channel flamingo.Channel) error {
+	return f(bot, channel)
+}
@@ -0,0 +1,14 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/mvader/flamingo"
+	"github.com/mvader/flamingo/conformance"
+)
+
+func TestBackendConformance(t *testing.T) {
+	conformance.Run(t, func() flamingo.Backend {
+		return NewClient("token", ClientOptions{})
+	})
+}